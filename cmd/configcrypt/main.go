@@ -0,0 +1,206 @@
+// Command configcrypt encrypts and decrypts ENC[AES256_GCM,...] values for
+// configx config files.
+//
+// Usage:
+//
+//	configcrypt keygen
+//	configcrypt encrypt [-key-file path] [value]
+//	configcrypt decrypt [-key-file path] [value]
+//	configcrypt encrypt [-key-file path] -file configs/base.yaml -path db.password [-path ...]
+//	configcrypt decrypt [-key-file path] -file configs/base.yaml -path db.password [-path ...]
+//
+// Without -file, the value is read from the argument or stdin and the result
+// is printed. With -file, the values at the given dot-separated paths of a
+// YAML config file are replaced in place; comments are kept.
+//
+// The key is read from -key-file, or else from the CONFIG_ENCRYPTION_KEY or
+// CONFIG_ENCRYPTION_KEY_FILE environment variables, as by configx.
+package main
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/gostratum/core/configx"
+	"go.yaml.in/yaml/v3"
+)
+
+func main() {
+	if err := run(os.Args[1:], os.Stdin, os.Stdout); err != nil {
+		fmt.Fprintln(os.Stderr, "configcrypt:", err)
+		os.Exit(1)
+	}
+}
+
+// pathList collects repeated -path flags.
+type pathList []string
+
+func (p *pathList) String() string     { return strings.Join(*p, ",") }
+func (p *pathList) Set(s string) error { *p = append(*p, s); return nil }
+
+func run(args []string, stdin io.Reader, stdout io.Writer) error {
+	if len(args) == 0 {
+		return errors.New("usage: configcrypt keygen|encrypt|decrypt [flags] [value]")
+	}
+	cmd := args[0]
+
+	fs := flag.NewFlagSet("configcrypt "+cmd, flag.ContinueOnError)
+	keyFile := fs.String("key-file", "", "file holding the encryption key (default: $"+configx.EnvEncryptionKey+" or $"+configx.EnvEncryptionKeyFile+")")
+	file := fs.String("file", "", "YAML config file to edit in place")
+	var paths pathList
+	fs.Var(&paths, "path", "dot-separated key in -file to encrypt or decrypt (repeatable)")
+	if err := fs.Parse(args[1:]); err != nil {
+		return err
+	}
+
+	var transform func(key []byte, s string) (string, error)
+	switch cmd {
+	case "keygen":
+		key := make([]byte, 32)
+		if _, err := rand.Read(key); err != nil {
+			return err
+		}
+		_, err := fmt.Fprintln(stdout, base64.StdEncoding.EncodeToString(key))
+		return err
+	case "encrypt":
+		transform = func(key []byte, s string) (string, error) {
+			if configx.IsEncrypted(s) {
+				return s, nil
+			}
+			return configx.EncryptValue(key, s)
+		}
+	case "decrypt":
+		transform = func(key []byte, s string) (string, error) {
+			if !configx.IsEncrypted(s) {
+				return s, nil
+			}
+			return configx.DecryptValue(key, s)
+		}
+	default:
+		return fmt.Errorf("unknown command %q", cmd)
+	}
+
+	key, err := loadKey(*keyFile)
+	if err != nil {
+		return err
+	}
+
+	if *file == "" {
+		value, err := readValue(fs.Args(), stdin)
+		if err != nil {
+			return err
+		}
+		out, err := transform(key, value)
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(stdout, out)
+		return err
+	}
+
+	if len(paths) == 0 {
+		return errors.New("-file requires at least one -path")
+	}
+	return editFile(*file, paths, func(s string) (string, error) { return transform(key, s) })
+}
+
+func loadKey(keyFile string) ([]byte, error) {
+	if keyFile != "" {
+		return configx.ReadEncryptionKeyFile(keyFile)
+	}
+	key, err := configx.EncryptionKeyFromEnv()
+	if errors.Is(err, configx.ErrNoEncryptionKey) {
+		return nil, fmt.Errorf("%w: use -key-file or set %s", err, configx.EnvEncryptionKey)
+	}
+	return key, err
+}
+
+func readValue(args []string, stdin io.Reader) (string, error) {
+	if len(args) > 1 {
+		return "", errors.New("expected a single value")
+	}
+	if len(args) == 1 {
+		return args[0], nil
+	}
+	b, err := io.ReadAll(stdin)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(string(b), "\r\n"), nil
+}
+
+// editFile replaces the scalar values at paths in a YAML file.
+func editFile(path string, paths []string, transform func(string) (string, error)) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	src, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal(src, &doc); err != nil {
+		return fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	if len(doc.Content) == 0 {
+		return fmt.Errorf("%s is empty", path)
+	}
+
+	for _, p := range paths {
+		node, err := findScalar(doc.Content[0], strings.Split(p, "."))
+		if err != nil {
+			return fmt.Errorf("%s: %w", p, err)
+		}
+		value, err := transform(node.Value)
+		if err != nil {
+			return fmt.Errorf("%s: %w", p, err)
+		}
+		if value != node.Value {
+			// Decrypted numbers and booleans stay strings, as they were encrypted
+			node.Value, node.Tag, node.Style = value, "!!str", 0
+		}
+	}
+
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(&doc); err != nil {
+		return err
+	}
+	if err := enc.Close(); err != nil {
+		return err
+	}
+	return os.WriteFile(path, buf.Bytes(), info.Mode().Perm())
+}
+
+func findScalar(node *yaml.Node, keys []string) (*yaml.Node, error) {
+	for _, k := range keys {
+		if node.Kind != yaml.MappingNode {
+			return nil, fmt.Errorf("key %q is not inside a map", k)
+		}
+		var next *yaml.Node
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if node.Content[i].Value == k {
+				next = node.Content[i+1]
+				break
+			}
+		}
+		if next == nil {
+			return nil, fmt.Errorf("key %q not found", k)
+		}
+		node = next
+	}
+	if node.Kind != yaml.ScalarNode {
+		return nil, errors.New("value is not a scalar")
+	}
+	return node, nil
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gostratum/core/configx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testKey = []byte("0123456789abcdef0123456789abcdef")
+
+func writeKeyFile(t *testing.T) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.key")
+	require.NoError(t, os.WriteFile(path, []byte(base64.StdEncoding.EncodeToString(testKey)+"\n"), 0o600))
+	return path
+}
+
+func TestRun_Keygen(t *testing.T) {
+	var out bytes.Buffer
+	require.NoError(t, run([]string{"keygen"}, nil, &out))
+
+	key, err := configx.ParseEncryptionKey(out.String())
+	require.NoError(t, err)
+	assert.Len(t, key, 32)
+}
+
+func TestRun_Value(t *testing.T) {
+	keyFile := writeKeyFile(t)
+
+	var enc bytes.Buffer
+	require.NoError(t, run([]string{"encrypt", "-key-file", keyFile, "hunter2"}, nil, &enc))
+	assert.True(t, configx.IsEncrypted(strings.TrimSpace(enc.String())))
+
+	// Value from stdin, key from env
+	t.Setenv(configx.EnvEncryptionKey, base64.StdEncoding.EncodeToString(testKey))
+	var dec bytes.Buffer
+	require.NoError(t, run([]string{"decrypt"}, strings.NewReader(enc.String()), &dec))
+	assert.Equal(t, "hunter2\n", dec.String())
+}
+
+func TestRun_File(t *testing.T) {
+	keyFile := writeKeyFile(t)
+	path := filepath.Join(t.TempDir(), "base.yaml")
+	original := `# database settings
+db:
+  host: localhost # primary
+  port: 5432
+  password: hunter2
+`
+	require.NoError(t, os.WriteFile(path, []byte(original), 0o640))
+
+	require.NoError(t, run([]string{"encrypt", "-key-file", keyFile, "-file", path, "-path", "db.password", "-path", "db.port"}, nil, nil))
+
+	encrypted, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Contains(t, string(encrypted), "# database settings")
+	assert.Contains(t, string(encrypted), "host: localhost # primary")
+	assert.NotContains(t, string(encrypted), "hunter2")
+	assert.NotContains(t, string(encrypted), "5432")
+
+	// The loader decrypts the edited file
+	loader, err := configx.NewE(configx.WithFiles(path), configx.WithEncryptionKeyFile(keyFile))
+	require.NoError(t, err)
+	assert.Equal(t, "hunter2", loader.GetString("db.password"))
+	assert.Equal(t, 5432, loader.GetInt("db.port"))
+
+	// Encrypting twice leaves values alone
+	require.NoError(t, run([]string{"encrypt", "-key-file", keyFile, "-file", path, "-path", "db.password"}, nil, nil))
+	again, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, string(encrypted), string(again))
+
+	require.NoError(t, run([]string{"decrypt", "-key-file", keyFile, "-file", path, "-path", "db.password", "-path", "db.port"}, nil, nil))
+	decrypted, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Contains(t, string(decrypted), "password: hunter2")
+	assert.Contains(t, string(decrypted), `port: "5432"`)
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o640), info.Mode().Perm())
+}
+
+func TestRun_Errors(t *testing.T) {
+	keyFile := writeKeyFile(t)
+	path := filepath.Join(t.TempDir(), "base.yaml")
+	require.NoError(t, os.WriteFile(path, []byte("db:\n  hosts: [a, b]\n"), 0o600))
+
+	tests := []struct {
+		name string
+		args []string
+		want string
+	}{
+		{"no command", nil, "usage"},
+		{"unknown command", []string{"rotate"}, "unknown command"},
+		{"no key", []string{"encrypt", "x"}, "no config encryption key"},
+		{"missing path", []string{"encrypt", "-key-file", keyFile, "-file", path}, "requires at least one -path"},
+		{"unknown key", []string{"encrypt", "-key-file", keyFile, "-file", path, "-path", "db.user"}, `key "user" not found`},
+		{"not scalar", []string{"encrypt", "-key-file", keyFile, "-file", path, "-path", "db.hosts"}, "not a scalar"},
+		{"wrong key", []string{"decrypt", "-key-file", keyFile, "ENC[AES256_GCM,data:AAAA,iv:AAAAAAAAAAAAAAAA,tag:AAAAAAAAAAAAAAAAAAAAAA==]"}, "wrong key"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := run(tt.args, strings.NewReader(""), &bytes.Buffer{})
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.want)
+		})
+	}
+}
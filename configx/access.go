@@ -0,0 +1,129 @@
+package configx
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/mitchellh/mapstructure"
+	"github.com/spf13/cast"
+)
+
+// key resolves a key relative to the loader scope into a full viper key.
+func (l *viperLoader) key(key string) string {
+	key = normalizeKey(key)
+	if l.scope == "" {
+		return key
+	}
+	if key == "" {
+		return l.scope
+	}
+	return l.scope + "." + key
+}
+
+// GetString returns the value of key as a string, or "" when it is not set.
+func (l *viperLoader) GetString(key string) string {
+	if l.loadErr != nil {
+		return ""
+	}
+	return cast.ToString(l.get(l.key(key)))
+}
+
+// GetInt returns the value of key as an int, or 0 when it is not set.
+func (l *viperLoader) GetInt(key string) int {
+	if l.loadErr != nil {
+		return 0
+	}
+	return cast.ToInt(l.get(l.key(key)))
+}
+
+// GetDuration returns the value of key as a time.Duration, or 0 when it is
+// not set.
+func (l *viperLoader) GetDuration(key string) time.Duration {
+	if l.loadErr != nil {
+		return 0
+	}
+	return cast.ToDuration(l.get(l.key(key)))
+}
+
+// GetStringMap returns the subtree below key with env overrides applied to
+// its nested keys, or an empty map when nothing is set.
+func (l *viperLoader) GetStringMap(key string) map[string]any {
+	if l.loadErr != nil {
+		return map[string]any{}
+	}
+	return l.settings(l.key(key))
+}
+
+// IsSet reports whether key has a value from a config file, an environment
+// variable or a BindEnv binding.
+func (l *viperLoader) IsSet(key string) bool {
+	if l.loadErr != nil {
+		return false
+	}
+	k := l.key(key)
+	if l.isSet(k) {
+		return true
+	}
+	return len(l.settings(k)) > 0
+}
+
+// Lookup returns the raw value of key. Subtrees are returned as
+// map[string]any with env overrides applied. It returns an error wrapping
+// ErrConfigNotFound when the key is not set, or the error that failed
+// loading the config files.
+func (l *viperLoader) Lookup(key string) (any, error) {
+	if l.loadErr != nil {
+		return nil, l.loadErr
+	}
+	k := l.key(key)
+	if sub := l.settings(k); len(sub) > 0 {
+		return sub, nil
+	}
+	if !l.isSet(k) {
+		return nil, fmt.Errorf("%w: %s", ErrConfigNotFound, k)
+	}
+	return l.get(k), nil
+}
+
+// Sub returns a Loader scoped to prefix. Keys passed to the scoped loader,
+// including Configurable prefixes given to Bind, are relative to prefix.
+// The scoped loader shares settings and BindEnv bindings with its parent.
+func (l *viperLoader) Sub(prefix string) Loader {
+	sub := *l
+	sub.scope = l.key(prefix)
+	return &sub
+}
+
+// Value looks up key and decodes it into T with the loader's decode hooks,
+// so strings such as "30s" convert to time.Duration. It returns an error
+// wrapping ErrConfigNotFound when the key is not set.
+//
+// Example:
+//
+//	timeout, err := configx.Value[time.Duration](loader, "http.timeout")
+//	hosts, err := configx.Value[[]string](loader, "kafka.brokers")
+func Value[T any](l Loader, key string) (T, error) {
+	var out T
+	raw, err := l.Lookup(key)
+	if err != nil {
+		return out, err
+	}
+
+	hook := defaultDecodeHook()
+	if vl, ok := l.(*viperLoader); ok {
+		hook = vl.decodeHook
+	}
+
+	decoder, err := mapstructure.NewDecoder(&mapstructure.DecoderConfig{
+		Result:           &out,
+		DecodeHook:       hook,
+		WeaklyTypedInput: true,
+	})
+	if err != nil {
+		return out, fmt.Errorf("failed to create decoder: %w", err)
+	}
+	if err := decoder.Decode(raw); err != nil {
+		return out, fmt.Errorf("failed to decode config key '%s': %w", key, err)
+	}
+	return out, nil
+}
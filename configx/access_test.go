@@ -0,0 +1,151 @@
+package configx
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const accessYAML = `
+http:
+  port: 8080
+  host: example.com
+  timeout: 30s
+  headers:
+    x-env: test
+db:
+  primary:
+    host: db1
+    port: 5432
+`
+
+type scopedDBConfig struct {
+	Host string `mapstructure:"host"`
+	Port int    `mapstructure:"port"`
+}
+
+func (scopedDBConfig) Prefix() string { return "primary" }
+
+func TestLoader_TypedGetters(t *testing.T) {
+	loader := newTestLoader(t, accessYAML)
+
+	assert.Equal(t, "example.com", loader.GetString("http.host"))
+	assert.Equal(t, 8080, loader.GetInt("http.port"))
+	assert.Equal(t, 30*time.Second, loader.GetDuration("http.timeout"))
+	assert.Equal(t, map[string]any{"x-env": "test"}, loader.GetStringMap("http.headers"))
+	assert.True(t, loader.IsSet("http.port"))
+	assert.True(t, loader.IsSet("HTTP.Port"))
+	assert.False(t, loader.IsSet("http.missing"))
+
+	assert.Equal(t, "", loader.GetString("http.missing"))
+	assert.Equal(t, 0, loader.GetInt("http.missing"))
+	assert.Empty(t, loader.GetStringMap("missing"))
+}
+
+func TestLoader_GettersRespectEnv(t *testing.T) {
+	t.Setenv("STRATUM_HTTP_PORT", "9090")
+	t.Setenv("STRATUM_DB_PRIMARY_HOST", "env-db")
+	t.Setenv("DATABASE_PASSWORD", "secret")
+
+	loader := newTestLoader(t, accessYAML)
+	require.NoError(t, loader.BindEnv("db.primary.password", "DATABASE_PASSWORD"))
+
+	assert.Equal(t, 9090, loader.GetInt("http.port"))
+	assert.Equal(t, "secret", loader.GetString("db.primary.password"))
+	assert.True(t, loader.IsSet("db.primary.password"))
+
+	primary := loader.GetStringMap("db.primary")
+	assert.Equal(t, "env-db", primary["host"])
+	assert.Equal(t, "secret", primary["password"])
+}
+
+func TestLoader_Lookup(t *testing.T) {
+	loader := newTestLoader(t, accessYAML)
+
+	v, err := loader.Lookup("http.host")
+	require.NoError(t, err)
+	assert.Equal(t, "example.com", v)
+
+	v, err = loader.Lookup("db.primary")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{"host": "db1", "port": 5432}, v)
+
+	_, err = loader.Lookup("http.missing")
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, ErrConfigNotFound))
+	assert.Contains(t, err.Error(), "http.missing")
+}
+
+func TestValue(t *testing.T) {
+	loader := newTestLoader(t, accessYAML)
+
+	timeout, err := Value[time.Duration](loader, "http.timeout")
+	require.NoError(t, err)
+	assert.Equal(t, 30*time.Second, timeout)
+
+	port, err := Value[string](loader, "http.port")
+	require.NoError(t, err)
+	assert.Equal(t, "8080", port)
+
+	db, err := Value[scopedDBConfig](loader, "db.primary")
+	require.NoError(t, err)
+	assert.Equal(t, scopedDBConfig{Host: "db1", Port: 5432}, db)
+
+	_, err = Value[int](loader, "nope")
+	assert.ErrorIs(t, err, ErrConfigNotFound)
+
+	_, err = Value[int](loader, "http.host")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "http.host")
+}
+
+func TestLoader_Sub(t *testing.T) {
+	t.Setenv("STRATUM_DB_PRIMARY_PORT", "6543")
+	loader := newTestLoader(t, accessYAML)
+
+	db := loader.Sub("db")
+	assert.Equal(t, "db1", db.GetString("primary.host"))
+	assert.Equal(t, 6543, db.GetInt("primary.port"))
+	assert.False(t, db.IsSet("http.port"))
+
+	var cfg scopedDBConfig
+	require.NoError(t, db.Bind(&cfg))
+	assert.Equal(t, "db1", cfg.Host)
+	assert.Equal(t, 6543, cfg.Port)
+
+	primary := db.Sub("primary")
+	assert.Equal(t, "db1", primary.GetString("host"))
+
+	_, err := primary.Lookup("missing")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "db.primary.missing")
+
+	t.Run("BindEnv is relative to scope", func(t *testing.T) {
+		t.Setenv("DB_PRIMARY_USER", "scoped-user")
+		require.NoError(t, primary.BindEnv("user"))
+		assert.Equal(t, "scoped-user", loader.GetString("db.primary.user"))
+	})
+}
+
+func TestLoader_LoadError(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "base.yaml"), []byte("app:\n  s: ok\n"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "broken.yaml"), []byte("app: [\n"), 0o644))
+	loader := New(WithConfigPaths(dir), WithFiles(filepath.Join(dir, "broken.yaml")))
+
+	_, err := loader.Lookup("app.s")
+	assert.ErrorContains(t, err, "broken.yaml")
+	assert.NotErrorIs(t, err, ErrConfigNotFound)
+	_, err = Value[string](loader, "app.s")
+	assert.ErrorContains(t, err, "broken.yaml")
+
+	// The getters do not return values from the partially loaded config
+	assert.Equal(t, "", loader.GetString("app.s"))
+	assert.False(t, loader.IsSet("app.s"))
+	assert.Empty(t, loader.GetStringMap("app"))
+}
@@ -1,14 +1,59 @@
 package configx
 
+import "time"
+
 // Loader loads configuration into structs with validation.
+//
+// Bind fills a struct in three layers, each overriding the one before:
+//
+//  1. default:"..." struct tags (and @provider defaults)
+//  2. config files, in load order
+//  3. environment variables
+//
+// A key that is present in a file or the environment always wins over its
+// default, even when the value is a zero value such as false, 0 or "".
+// Nested structs and maps are merged key by key, while a configured list
+// replaces the default list. SetDefaults (see DefaultsSetter) runs last,
+// before validation, and should only fill fields that are still unset.
+//
+// Keys passed to the getters are dot-separated (e.g. "db.host") and follow
+// the same env override and BindEnv rules as Bind.
 type Loader interface {
 	// Bind loads configuration into a struct implementing Configurable.
-	// Configuration precedence: ENV > YAML > Defaults
+	// Configuration precedence: ENV > files > Defaults
 	Bind(Configurable) error
 
 	// BindEnv explicitly binds a key to environment variables.
 	// Use for sensitive values that should only come from environment.
 	BindEnv(key string, envVars ...string) error
+
+	// LoadedFiles returns the config files that were merged, in load order.
+	// Included files appear before the file that includes them.
+	LoadedFiles() []string
+
+	// The getters below return zero values ("", 0, an empty map, false)
+	// when config files failed to load with New; Bind and Lookup return
+	// that error instead.
+
+	// GetString returns the value of key as a string, or "" when unset.
+	GetString(key string) string
+	// GetInt returns the value of key as an int, or 0 when unset.
+	GetInt(key string) int
+	// GetDuration returns the value of key as a time.Duration, or 0 when unset.
+	GetDuration(key string) time.Duration
+	// GetStringMap returns the subtree below key, or an empty map when unset.
+	GetStringMap(key string) map[string]any
+	// IsSet reports whether key has a value.
+	IsSet(key string) bool
+
+	// Lookup returns the raw value of key, or an error wrapping
+	// ErrConfigNotFound when the key is unset. It returns the load error
+	// when config files failed to load with New. See Value for typed lookups.
+	Lookup(key string) (any, error)
+
+	// Sub returns a Loader scoped to prefix; all keys and Configurable
+	// prefixes used with it are relative to prefix.
+	Sub(prefix string) Loader
 }
 
 // Configurable must be implemented by configuration structs.
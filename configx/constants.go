@@ -11,12 +11,29 @@ const (
 	EnvConfigPaths = "CONFIG_PATHS"
 
 	// EnvAppEnv is the environment variable name for application environment.
+	// It may hold a comma-separated list of overlays applied in order.
 	EnvAppEnv = "APP_ENV"
 
+	// EnvAppProfiles is the environment variable name for a comma-separated
+	// list of config overlays. If set, it takes precedence over APP_ENV.
+	EnvAppProfiles = "APP_PROFILES"
+
 	// EnvPrefix is the environment variable name to override the default prefix.
 	// If set, this takes precedence over DefaultEnvPrefix but can be overridden by WithEnvPrefix() option.
 	EnvPrefix = "ENV_PREFIX"
 
 	// BaseConfigFile is the base configuration file name (without extension).
 	BaseConfigFile = "base"
+
+	// FragmentDir is the subdirectory of each config path holding config
+	// fragments (*.yaml, *.yml) merged between base and the environment overlays.
+	FragmentDir = "conf.d"
+
+	// EnvEncryptionKey is the environment variable holding the base64 or hex
+	// encoded key for ENC[...] config values.
+	EnvEncryptionKey = "CONFIG_ENCRYPTION_KEY"
+
+	// EnvEncryptionKeyFile is the environment variable naming a file that
+	// holds the key for ENC[...] config values.
+	EnvEncryptionKeyFile = "CONFIG_ENCRYPTION_KEY_FILE"
 )
@@ -0,0 +1,254 @@
+package configx
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"reflect"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/mitchellh/mapstructure"
+)
+
+// DefaultsSetter is optionally implemented by Configurable types (and nested
+// config structs) that need defaults a struct tag cannot express, such as
+// values derived from other fields. Bind calls SetDefaults after config
+// values are decoded and before validation, innermost structs first.
+//
+// Example:
+//
+//	func (c *HTTPConfig) SetDefaults() {
+//	    if c.PublicURL == "" {
+//	        c.PublicURL = fmt.Sprintf("http://%s:%d", c.Host, c.Port)
+//	    }
+//	}
+type DefaultsSetter interface {
+	SetDefaults()
+}
+
+// DefaultProvider computes the value of a default:"@name" struct tag.
+// The result is decoded into the field with the loader's decode hooks, so a
+// provider may return e.g. a string for a time.Duration field.
+type DefaultProvider func() (any, error)
+
+var defaultProviders = struct {
+	sync.RWMutex
+	m map[string]DefaultProvider
+}{m: map[string]DefaultProvider{
+	"hostname":   func() (any, error) { return os.Hostname() },
+	"numcpu":     func() (any, error) { return runtime.NumCPU(), nil },
+	"gomaxprocs": func() (any, error) { return runtime.GOMAXPROCS(0), nil },
+	"tempdir":    func() (any, error) { return os.TempDir(), nil },
+}}
+
+// RegisterDefaultProvider registers a named provider for default:"@name"
+// struct tags, replacing any provider with the same name.
+// Built-in providers: hostname, numcpu, gomaxprocs, tempdir.
+//
+// Numeric providers accept a multiplier, e.g. default:"@gomaxprocs*2".
+//
+// Example:
+//
+//	configx.RegisterDefaultProvider("region", func() (any, error) {
+//	    return os.Getenv("AWS_REGION"), nil
+//	})
+//
+//	type Config struct {
+//	    Region  string `mapstructure:"region" default:"@region"`
+//	    Workers int    `mapstructure:"workers" default:"@numcpu*2"`
+//	}
+func RegisterDefaultProvider(name string, p DefaultProvider) {
+	if name = strings.TrimSpace(name); name == "" || p == nil {
+		return
+	}
+	defaultProviders.Lock()
+	defer defaultProviders.Unlock()
+	defaultProviders.m[name] = p
+}
+
+func lookupDefaultProvider(name string) (DefaultProvider, bool) {
+	defaultProviders.RLock()
+	defer defaultProviders.RUnlock()
+	p, ok := defaultProviders.m[name]
+	return p, ok
+}
+
+// applyDefaults sets zero-valued fields of the struct v from their default
+// tags, recursing into nested structs and non-nil struct pointers. Tag values
+// are decoded with the loader's decode hooks, so "30s" or "64MiB" work for
+// the matching types; slice, map and struct fields also accept JSON.
+// Pointer fields with a default tag are allocated.
+func applyDefaults(v reflect.Value, hook mapstructure.DecodeHookFunc) error {
+	for v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+	if !hasExportedFields(v.Type()) {
+		return nil
+	}
+
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		if err := setFieldDefault(v.Field(i), f.Tag.Get("default"), hook); err != nil {
+			return fmt.Errorf("default for field %s: %w", f.Name, err)
+		}
+	}
+	return nil
+}
+
+func setFieldDefault(field reflect.Value, tag string, hook mapstructure.DecodeHookFunc) error {
+	if tag == "-" {
+		return nil
+	}
+
+	if tag != "" && field.IsZero() {
+		if field.Kind() == reflect.Pointer {
+			field.Set(reflect.New(field.Type().Elem()))
+			return setFieldDefault(field.Elem(), tag, hook)
+		}
+		if err := decodeDefault(field, tag, hook); err != nil {
+			return err
+		}
+	}
+
+	// Nested structs get their own field defaults
+	return applyDefaults(field, hook)
+}
+
+// decodeDefault decodes a default tag value into field.
+func decodeDefault(field reflect.Value, tag string, hook mapstructure.DecodeHookFunc) error {
+	var val any = tag
+	if strings.HasPrefix(tag, "@") {
+		var err error
+		if val, err = providerDefault(tag[1:]); err != nil {
+			return err
+		}
+	} else if k := field.Kind(); (k == reflect.Slice || k == reflect.Map || k == reflect.Struct) &&
+		(strings.HasPrefix(tag, "[") || strings.HasPrefix(tag, "{")) {
+		return json.Unmarshal([]byte(tag), field.Addr().Interface())
+	}
+
+	decoder, err := mapstructure.NewDecoder(&mapstructure.DecoderConfig{
+		Result:           field.Addr().Interface(),
+		DecodeHook:       hook,
+		WeaklyTypedInput: true,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create decoder: %w", err)
+	}
+	return decoder.Decode(val)
+}
+
+// defaultsDecodeHook prepares every struct that mapstructure is about to
+// decode a map into. Zero structs, such as new slice elements or map values,
+// get their tag defaults first so that decoded values land on top of them.
+// Slice fields present in the input are cleared so a configured list replaces
+// the default list instead of overwriting it element by element.
+func defaultsDecodeHook(hook mapstructure.DecodeHookFunc) mapstructure.DecodeHookFuncValue {
+	return func(from, to reflect.Value) (any, error) {
+		if to.Kind() != reflect.Struct || !to.CanSet() || !hasExportedFields(to.Type()) {
+			return from.Interface(), nil
+		}
+		input, ok := from.Interface().(map[string]any)
+		if !ok {
+			return from.Interface(), nil
+		}
+
+		if to.IsZero() {
+			if err := applyDefaults(to, hook); err != nil {
+				return nil, err
+			}
+		}
+		clearProvidedSlices(to, input)
+		return input, nil
+	}
+}
+
+// clearProvidedSlices resets the slice fields of v whose keys are in input.
+func clearProvidedSlices(v reflect.Value, input map[string]any) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name, squash := fieldKey(f)
+		field := v.Field(i)
+		if squash && field.Kind() == reflect.Struct {
+			clearProvidedSlices(field, input)
+			continue
+		}
+		if !f.IsExported() || field.Kind() != reflect.Slice {
+			continue
+		}
+		for k := range input {
+			if strings.EqualFold(k, name) {
+				field.Set(reflect.Zero(field.Type()))
+				break
+			}
+		}
+	}
+}
+
+// callDefaultsSetters calls SetDefaults on v and every nested struct that
+// implements DefaultsSetter, innermost first.
+func callDefaultsSetters(v reflect.Value) {
+	for v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return
+		}
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct || !v.CanAddr() {
+		return
+	}
+
+	for i := 0; i < v.NumField(); i++ {
+		if v.Type().Field(i).IsExported() {
+			callDefaultsSetters(v.Field(i))
+		}
+	}
+	if s, ok := v.Addr().Interface().(DefaultsSetter); ok {
+		s.SetDefaults()
+	}
+}
+
+// providerDefault evaluates a provider expression of the form "name" or
+// "name*N".
+func providerDefault(expr string) (any, error) {
+	name, factor, hasFactor := strings.Cut(expr, "*")
+	name = strings.TrimSpace(name)
+
+	p, ok := lookupDefaultProvider(name)
+	if !ok {
+		return nil, fmt.Errorf("unknown default provider @%s", name)
+	}
+	val, err := p()
+	if err != nil {
+		return nil, fmt.Errorf("default provider @%s: %w", name, err)
+	}
+	if !hasFactor {
+		return val, nil
+	}
+
+	n, err := strconv.Atoi(strings.TrimSpace(factor))
+	if err != nil {
+		return nil, fmt.Errorf("invalid multiplier in @%s: %w", expr, err)
+	}
+	switch num := val.(type) {
+	case int:
+		return num * n, nil
+	case int64:
+		return num * int64(n), nil
+	case float64:
+		return num * float64(n), nil
+	default:
+		return nil, fmt.Errorf("default provider @%s returned %T, cannot multiply", name, val)
+	}
+}
@@ -0,0 +1,185 @@
+package configx
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type precedenceTLS struct {
+	Enabled bool   `mapstructure:"enabled" default:"true"`
+	MinVer  string `mapstructure:"min_version" default:"1.2"`
+}
+
+type precedenceUpstream struct {
+	Name    string        `mapstructure:"name"`
+	Weight  int           `mapstructure:"weight" default:"1"`
+	Timeout time.Duration `mapstructure:"timeout" default:"5s"`
+}
+
+type precedenceConfig struct {
+	Enabled   bool                          `mapstructure:"enabled" default:"true"`
+	Port      int                           `mapstructure:"port" default:"8080"`
+	Name      string                        `mapstructure:"name" default:"svc"`
+	Ratio     float64                       `mapstructure:"ratio" default:"0.5"`
+	BufSize   ByteSize                      `mapstructure:"buf_size" default:"64MiB"`
+	Tags      []string                      `mapstructure:"tags" default:"[\"a\",\"b\"]"`
+	Labels    map[string]string             `mapstructure:"labels" default:"{\"team\":\"core\"}"`
+	TLS       *precedenceTLS                `mapstructure:"tls" default:"{}"`
+	Upstreams []precedenceUpstream          `mapstructure:"upstreams"`
+	Routes    map[string]precedenceUpstream `mapstructure:"routes"`
+	Optional  *precedenceTLS                `mapstructure:"optional"`
+}
+
+func (precedenceConfig) Prefix() string { return "svc" }
+
+func TestBind_DefaultsWhenUnset(t *testing.T) {
+	var cfg precedenceConfig
+	require.NoError(t, newTestLoader(t, "").Bind(&cfg))
+
+	assert.True(t, cfg.Enabled)
+	assert.Equal(t, 8080, cfg.Port)
+	assert.Equal(t, "svc", cfg.Name)
+	assert.Equal(t, 0.5, cfg.Ratio)
+	assert.Equal(t, ByteSize(64<<20), cfg.BufSize)
+	assert.Equal(t, []string{"a", "b"}, cfg.Tags)
+	assert.Equal(t, map[string]string{"team": "core"}, cfg.Labels)
+	require.NotNil(t, cfg.TLS)
+	assert.True(t, cfg.TLS.Enabled)
+	assert.Equal(t, "1.2", cfg.TLS.MinVer)
+	// Untagged pointers stay nil when unset
+	assert.Nil(t, cfg.Optional)
+}
+
+func TestBind_ExplicitZeroValuesWinOverDefaults(t *testing.T) {
+	tests := []struct {
+		name  string
+		yaml  string
+		check func(t *testing.T, cfg precedenceConfig)
+	}{
+		{
+			name:  "false bool",
+			yaml:  "svc:\n  enabled: false\n",
+			check: func(t *testing.T, cfg precedenceConfig) { assert.False(t, cfg.Enabled) },
+		},
+		{
+			name:  "zero int",
+			yaml:  "svc:\n  port: 0\n",
+			check: func(t *testing.T, cfg precedenceConfig) { assert.Equal(t, 0, cfg.Port) },
+		},
+		{
+			name:  "zero float",
+			yaml:  "svc:\n  ratio: 0\n",
+			check: func(t *testing.T, cfg precedenceConfig) { assert.Equal(t, 0.0, cfg.Ratio) },
+		},
+		{
+			name:  "empty string",
+			yaml:  "svc:\n  name: \"\"\n",
+			check: func(t *testing.T, cfg precedenceConfig) { assert.Equal(t, "", cfg.Name) },
+		},
+		{
+			name:  "zero byte size",
+			yaml:  "svc:\n  buf_size: 0\n",
+			check: func(t *testing.T, cfg precedenceConfig) { assert.Equal(t, ByteSize(0), cfg.BufSize) },
+		},
+		{
+			name:  "empty list",
+			yaml:  "svc:\n  tags: []\n",
+			check: func(t *testing.T, cfg precedenceConfig) { assert.Empty(t, cfg.Tags) },
+		},
+		{
+			name: "nested pointer false bool",
+			yaml: "svc:\n  tls:\n    enabled: false\n",
+			check: func(t *testing.T, cfg precedenceConfig) {
+				require.NotNil(t, cfg.TLS)
+				assert.False(t, cfg.TLS.Enabled)
+				// Siblings keep their defaults
+				assert.Equal(t, "1.2", cfg.TLS.MinVer)
+			},
+		},
+		{
+			name: "nested pointer empty string",
+			yaml: "svc:\n  tls:\n    min_version: \"\"\n",
+			check: func(t *testing.T, cfg precedenceConfig) {
+				require.NotNil(t, cfg.TLS)
+				assert.True(t, cfg.TLS.Enabled)
+				assert.Equal(t, "", cfg.TLS.MinVer)
+			},
+		},
+		{
+			name: "untagged nested pointer gets field defaults",
+			yaml: "svc:\n  optional:\n    enabled: false\n",
+			check: func(t *testing.T, cfg precedenceConfig) {
+				require.NotNil(t, cfg.Optional)
+				assert.False(t, cfg.Optional.Enabled)
+				assert.Equal(t, "1.2", cfg.Optional.MinVer)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var cfg precedenceConfig
+			require.NoError(t, newTestLoader(t, tt.yaml).Bind(&cfg))
+			tt.check(t, cfg)
+		})
+	}
+}
+
+func TestBind_ExplicitZeroValuesFromEnv(t *testing.T) {
+	t.Setenv("STRATUM_SVC_ENABLED", "false")
+	t.Setenv("STRATUM_SVC_PORT", "0")
+	t.Setenv("STRATUM_SVC_TLS_ENABLED", "false")
+
+	var cfg precedenceConfig
+	require.NoError(t, newTestLoader(t, "svc:\n  enabled: true\n  port: 9000\n  tls:\n    enabled: true\n").Bind(&cfg))
+	assert.False(t, cfg.Enabled)
+	assert.Equal(t, 0, cfg.Port)
+	require.NotNil(t, cfg.TLS)
+	assert.False(t, cfg.TLS.Enabled)
+}
+
+func TestBind_ListsReplaceDefaults(t *testing.T) {
+	var cfg precedenceConfig
+	require.NoError(t, newTestLoader(t, "svc:\n  tags: [c]\n  labels:\n    env: prod\n").Bind(&cfg))
+	assert.Equal(t, []string{"c"}, cfg.Tags)
+	// Maps merge with their default entries
+	assert.Equal(t, map[string]string{"team": "core", "env": "prod"}, cfg.Labels)
+}
+
+func TestBind_DefaultsForListAndMapElements(t *testing.T) {
+	var cfg precedenceConfig
+	require.NoError(t, newTestLoader(t, `
+svc:
+  upstreams:
+    - name: a
+    - name: b
+      weight: 0
+      timeout: 1s
+  routes:
+    api:
+      name: api
+`).Bind(&cfg))
+
+	require.Len(t, cfg.Upstreams, 2)
+	assert.Equal(t, precedenceUpstream{Name: "a", Weight: 1, Timeout: 5 * time.Second}, cfg.Upstreams[0])
+	assert.Equal(t, precedenceUpstream{Name: "b", Weight: 0, Timeout: time.Second}, cfg.Upstreams[1])
+	assert.Equal(t, precedenceUpstream{Name: "api", Weight: 1, Timeout: 5 * time.Second}, cfg.Routes["api"])
+}
+
+func TestBind_InvalidDefaultTag(t *testing.T) {
+	loader := newTestLoader(t, "")
+
+	err := loader.Bind(&invalidDefaultConfig{})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to set defaults")
+	assert.Contains(t, err.Error(), "Port")
+}
+
+type invalidDefaultConfig struct {
+	Port int `mapstructure:"port" default:"not-a-number"`
+}
+
+func (invalidDefaultConfig) Prefix() string { return "invalid" }
@@ -0,0 +1,127 @@
+package configx
+
+import (
+	"errors"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type providerNested struct {
+	Dir string `mapstructure:"dir" default:"@tempdir"`
+}
+
+type providerConfig struct {
+	Host     string          `mapstructure:"host" default:"@hostname"`
+	Workers  int             `mapstructure:"workers" default:"@gomaxprocs*2"`
+	CPUs     int             `mapstructure:"cpus" default:"@numcpu"`
+	Interval time.Duration   `mapstructure:"interval" default:"@test-interval"`
+	Nested   providerNested  `mapstructure:"nested"`
+	Ptr      *providerNested `mapstructure:"ptr"`
+	URL      string          `mapstructure:"url"`
+	Port     int             `mapstructure:"port" default:"8080"`
+}
+
+func (providerConfig) Prefix() string { return "provider" }
+
+// SetDefaults derives URL from fields that are already set when it runs.
+func (c *providerConfig) SetDefaults() {
+	if c.URL == "" {
+		c.URL = "http://" + c.Host + ":" + strconv.Itoa(c.Port)
+	}
+}
+
+type badProviderConfig struct {
+	Value string `mapstructure:"value" default:"@does-not-exist"`
+}
+
+func (badProviderConfig) Prefix() string { return "bad" }
+
+type failingProviderConfig struct {
+	Value string `mapstructure:"value" default:"@test-failing"`
+}
+
+func (failingProviderConfig) Prefix() string { return "failing" }
+
+func init() {
+	RegisterDefaultProvider("test-interval", func() (any, error) { return "45s", nil })
+	RegisterDefaultProvider("test-failing", func() (any, error) { return nil, errors.New("boom") })
+}
+
+func TestBind_ProviderDefaults(t *testing.T) {
+	hostname, err := os.Hostname()
+	require.NoError(t, err)
+
+	loader, err := NewWithReader(strings.NewReader("provider:\n  ptr:\n    dir: \"\"\n"))
+	require.NoError(t, err)
+
+	var cfg providerConfig
+	require.NoError(t, loader.Bind(&cfg))
+
+	assert.Equal(t, hostname, cfg.Host)
+	assert.Equal(t, runtime.GOMAXPROCS(0)*2, cfg.Workers)
+	assert.Equal(t, runtime.NumCPU(), cfg.CPUs)
+	assert.Equal(t, 45*time.Second, cfg.Interval)
+	assert.Equal(t, os.TempDir(), cfg.Nested.Dir)
+	// An explicit empty value wins over the provider default
+	require.NotNil(t, cfg.Ptr)
+	assert.Equal(t, "", cfg.Ptr.Dir)
+}
+
+func TestBind_ProviderDefaultsDoNotOverrideValues(t *testing.T) {
+	loader, err := NewWithReader(strings.NewReader("provider:\n  host: example.com\n  workers: 3\n"))
+	require.NoError(t, err)
+
+	var cfg providerConfig
+	require.NoError(t, loader.Bind(&cfg))
+	assert.Equal(t, "example.com", cfg.Host)
+	assert.Equal(t, 3, cfg.Workers)
+}
+
+func TestBind_SetDefaultsHook(t *testing.T) {
+	loader, err := NewWithReader(strings.NewReader("provider:\n  host: example.com\n"))
+	require.NoError(t, err)
+
+	var cfg providerConfig
+	require.NoError(t, loader.Bind(&cfg))
+	// Runs after tag defaults (port) and provider defaults
+	assert.Equal(t, "http://example.com:8080", cfg.URL)
+
+	loader, err = NewWithReader(strings.NewReader("provider:\n  url: https://explicit\n"))
+	require.NoError(t, err)
+
+	cfg = providerConfig{}
+	require.NoError(t, loader.Bind(&cfg))
+	assert.Equal(t, "https://explicit", cfg.URL)
+}
+
+func TestBind_ProviderDefaultErrors(t *testing.T) {
+	loader, err := NewWithReader(strings.NewReader(""))
+	require.NoError(t, err)
+
+	err = loader.Bind(&badProviderConfig{})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "unknown default provider @does-not-exist")
+
+	err = loader.Bind(&failingProviderConfig{})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "boom")
+}
+
+func TestProviderDefault(t *testing.T) {
+	v, err := providerDefault("numcpu * 3")
+	require.NoError(t, err)
+	assert.Equal(t, runtime.NumCPU()*3, v)
+
+	_, err = providerDefault("numcpu*x")
+	assert.Error(t, err)
+
+	_, err = providerDefault("hostname*2")
+	assert.Error(t, err)
+}
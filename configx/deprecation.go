@@ -0,0 +1,197 @@
+package configx
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+)
+
+// KeyAlias maps a renamed or deprecated config key to its replacement.
+// Keys are full dot-separated keys (relative to the scope of a Sub loader),
+// so a key may move between prefixes.
+type KeyAlias struct {
+	// Old is the deprecated key, e.g. "core.log.lvl".
+	Old string
+	// New is the key that replaces it, e.g. "core.logger.level".
+	New string
+	// RemovedIn is the release that stops reading Old, e.g. "v2.0.0".
+	RemovedIn string
+}
+
+// KeyAliaser is optionally implemented by Configurable types that renamed
+// keys. Bind reads a value set under KeyAlias.Old into KeyAlias.New when New
+// is not set itself, and logs a deprecation warning once per loader. A whole
+// subtree can be renamed by aliasing its parent key.
+//
+// With WithStrictDeprecations, Bind fails with ErrDeprecatedKey instead.
+//
+// Example:
+//
+//	func (DBConfig) KeyAliases() []configx.KeyAlias {
+//	    return []configx.KeyAlias{
+//	        {Old: "db.url", New: "db.dsn", RemovedIn: "v2.0.0"},
+//	        {Old: "database", New: "db", RemovedIn: "v2.0.0"},
+//	    }
+//	}
+type KeyAliaser interface {
+	KeyAliases() []KeyAlias
+}
+
+// applyKeyAliases copies values of deprecated keys set in config files or
+// env vars into settings, the map Bind decodes for prefix. Values already
+// present under the new key win.
+func (l *viperLoader) applyKeyAliases(settings map[string]any, prefix string, props Configurable) error {
+	aliaser, ok := props.(KeyAliaser)
+	if !ok {
+		return nil
+	}
+
+	for _, alias := range aliaser.KeyAliases() {
+		oldKey, newKey := l.key(alias.Old), l.key(alias.New)
+		if oldKey == "" || newKey == "" || !l.isSet(oldKey) {
+			continue
+		}
+
+		if l.strictDeprecations {
+			return fmt.Errorf("%w: %q, use %q instead%s", ErrDeprecatedKey, alias.Old, alias.New, removedIn(alias))
+		}
+		l.warnDeprecated(alias, props)
+
+		var rel []string
+		switch {
+		case newKey == prefix:
+		case strings.HasPrefix(newKey, prefix+"."):
+			rel = strings.Split(strings.TrimPrefix(newKey, prefix+"."), ".")
+		default:
+			// The new key belongs to another Configurable
+			continue
+		}
+
+		src := map[string]any{}
+		val := copyValue(l.get(oldKey))
+		if len(rel) == 0 {
+			m, ok := val.(map[string]any)
+			if !ok {
+				return fmt.Errorf("deprecated key %q must hold a map to alias %q", alias.Old, alias.New)
+			}
+			src = m
+		} else {
+			setNestedValue(src, rel, val)
+		}
+		mergeMissing(settings, src)
+	}
+	return nil
+}
+
+// warnDeprecated logs a deprecation warning the first time alias is used.
+func (l *viperLoader) warnDeprecated(alias KeyAlias, props Configurable) {
+	attrs := []any{
+		slog.String("deprecated", l.key(alias.Old)),
+		slog.String("replacement", l.key(alias.New)),
+		slog.String("config", fmt.Sprintf("%T", props)),
+	}
+	if alias.RemovedIn != "" {
+		attrs = append(attrs, slog.String("removed_in", alias.RemovedIn))
+	}
+	l.warnings.warnOnce("deprecated:"+l.key(alias.Old), "deprecated config key", attrs...)
+}
+
+// warningLog logs loader warnings once each. Until a logger is set with
+// WithLogger or SetLogger, warnings are kept and logged when it is.
+type warningLog struct {
+	mu      sync.Mutex
+	logger  *slog.Logger
+	seen    map[string]bool
+	pending []warning
+}
+
+type warning struct {
+	msg   string
+	attrs []any
+}
+
+func newWarningLog(logger *slog.Logger) *warningLog {
+	return &warningLog{logger: logger, seen: map[string]bool{}}
+}
+
+// warnOnce logs msg the first time it is called with id.
+func (w *warningLog) warnOnce(id, msg string, attrs ...any) {
+	w.mu.Lock()
+	if w.seen[id] {
+		w.mu.Unlock()
+		return
+	}
+	w.seen[id] = true
+	logger := w.logger
+	if logger == nil {
+		w.pending = append(w.pending, warning{msg: msg, attrs: attrs})
+	}
+	w.mu.Unlock()
+
+	if logger != nil {
+		logger.Warn(msg, attrs...)
+	}
+}
+
+// SetLogger sets the logger for the warnings of loader and the loaders
+// returned by its Sub method, and logs the warnings kept while there was
+// none, such as those of configs bound before the application logger
+// existed. logx.Module calls it with its slog logger while the app runs.
+// A nil logger keeps later warnings until the next call. Loaders not created
+// by this package are left alone.
+func SetLogger(loader Loader, logger *slog.Logger) {
+	vl, ok := loader.(*viperLoader)
+	if !ok {
+		return
+	}
+	w := vl.warnings
+	w.mu.Lock()
+	w.logger = logger
+	var pending []warning
+	if logger != nil {
+		pending, w.pending = w.pending, nil
+	}
+	w.mu.Unlock()
+
+	for _, p := range pending {
+		logger.Warn(p.msg, p.attrs...)
+	}
+}
+
+func removedIn(alias KeyAlias) string {
+	if alias.RemovedIn == "" {
+		return ""
+	}
+	return " (removed in " + alias.RemovedIn + ")"
+}
+
+// copyValue deep-copies nested maps so settings never share maps with viper.
+func copyValue(v any) any {
+	m, ok := v.(map[string]any)
+	if !ok {
+		return v
+	}
+	out := make(map[string]any, len(m))
+	for k, val := range m {
+		out[k] = copyValue(val)
+	}
+	return out
+}
+
+// mergeMissing copies the entries of src that dst does not have, merging
+// nested maps.
+func mergeMissing(dst, src map[string]any) {
+	for k, v := range src {
+		existing, ok := dst[k]
+		if !ok {
+			dst[k] = v
+			continue
+		}
+		dm, dok := existing.(map[string]any)
+		sm, sok := v.(map[string]any)
+		if dok && sok {
+			mergeMissing(dm, sm)
+		}
+	}
+}
@@ -0,0 +1,160 @@
+package configx
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type aliasConfig struct {
+	DSN     string `mapstructure:"dsn"`
+	Pool    int    `mapstructure:"pool" default:"4"`
+	Timeout string `mapstructure:"timeout"`
+}
+
+func (aliasConfig) Prefix() string { return "db" }
+
+func (aliasConfig) KeyAliases() []KeyAlias {
+	return []KeyAlias{
+		{Old: "db.url", New: "db.dsn", RemovedIn: "v2.0.0"},
+		{Old: "database", New: "db"},
+	}
+}
+
+func aliasLoader(t *testing.T, yaml string, opts ...Option) (Loader, *bytes.Buffer) {
+	t.Helper()
+	var buf bytes.Buffer
+	opts = append(opts, WithLogger(slog.New(slog.NewJSONHandler(&buf, nil))))
+	return newTestLoader(t, yaml, opts...), &buf
+}
+
+func logLines(t *testing.T, buf *bytes.Buffer) []map[string]any {
+	t.Helper()
+	var lines []map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		var m map[string]any
+		require.NoError(t, json.Unmarshal([]byte(line), &m))
+		lines = append(lines, m)
+	}
+	return lines
+}
+
+func TestBind_KeyAliasReadsOldKey(t *testing.T) {
+	loader, buf := aliasLoader(t, "db:\n  url: postgres://old\n")
+
+	var cfg aliasConfig
+	require.NoError(t, loader.Bind(&cfg))
+	assert.Equal(t, "postgres://old", cfg.DSN)
+
+	lines := logLines(t, buf)
+	require.Len(t, lines, 1)
+	assert.Equal(t, "WARN", lines[0]["level"])
+	assert.Equal(t, "deprecated config key", lines[0]["msg"])
+	assert.Equal(t, "db.url", lines[0]["deprecated"])
+	assert.Equal(t, "db.dsn", lines[0]["replacement"])
+	assert.Equal(t, "v2.0.0", lines[0]["removed_in"])
+	assert.Equal(t, "*configx.aliasConfig", lines[0]["config"])
+}
+
+func TestBind_KeyAliasWarnsOnce(t *testing.T) {
+	loader, buf := aliasLoader(t, "db:\n  url: postgres://old\n")
+
+	for range 3 {
+		var cfg aliasConfig
+		require.NoError(t, loader.Bind(&cfg))
+	}
+	// Loaders returned by Sub share the warning state
+	var cfg aliasConfig
+	require.NoError(t, loader.Sub("").Bind(&cfg))
+
+	assert.Len(t, logLines(t, buf), 1)
+}
+
+func TestBind_KeyAliasNewKeyWins(t *testing.T) {
+	loader, _ := aliasLoader(t, "db:\n  url: postgres://old\n  dsn: postgres://new\n")
+
+	var cfg aliasConfig
+	require.NoError(t, loader.Bind(&cfg))
+	assert.Equal(t, "postgres://new", cfg.DSN)
+
+	t.Setenv("STRATUM_DB_DSN", "postgres://env")
+	loader, _ = aliasLoader(t, "db:\n  url: postgres://old\n")
+	cfg = aliasConfig{}
+	require.NoError(t, loader.Bind(&cfg))
+	assert.Equal(t, "postgres://env", cfg.DSN)
+}
+
+func TestBind_KeyAliasFromEnv(t *testing.T) {
+	t.Setenv("STRATUM_DB_URL", "postgres://env-old")
+	loader, buf := aliasLoader(t, "")
+
+	var cfg aliasConfig
+	require.NoError(t, loader.Bind(&cfg))
+	assert.Equal(t, "postgres://env-old", cfg.DSN)
+	assert.Len(t, logLines(t, buf), 1)
+}
+
+func TestBind_KeyAliasSubtree(t *testing.T) {
+	loader, buf := aliasLoader(t, "database:\n  dsn: postgres://legacy\n  pool: 0\ndb:\n  timeout: 5s\n")
+
+	var cfg aliasConfig
+	require.NoError(t, loader.Bind(&cfg))
+	assert.Equal(t, aliasConfig{DSN: "postgres://legacy", Pool: 0, Timeout: "5s"}, cfg)
+
+	lines := logLines(t, buf)
+	require.Len(t, lines, 1)
+	assert.Equal(t, "database", lines[0]["deprecated"])
+	assert.NotContains(t, lines[0], "removed_in")
+}
+
+func TestBind_KeyAliasSubtreeMustBeMap(t *testing.T) {
+	loader, _ := aliasLoader(t, "database: postgres://legacy\n")
+
+	err := loader.Bind(&aliasConfig{})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `deprecated key "database" must hold a map`)
+}
+
+func TestBind_StrictDeprecations(t *testing.T) {
+	loader, buf := aliasLoader(t, "db:\n  url: postgres://old\n", WithStrictDeprecations())
+
+	err := loader.Bind(&aliasConfig{})
+	require.ErrorIs(t, err, ErrDeprecatedKey)
+	assert.Contains(t, err.Error(), `"db.url", use "db.dsn" instead (removed in v2.0.0)`)
+	assert.Empty(t, buf.String())
+
+	// Configs that do not use deprecated keys still bind
+	loader, _ = aliasLoader(t, "db:\n  dsn: postgres://new\n", WithStrictDeprecations())
+	var cfg aliasConfig
+	require.NoError(t, loader.Bind(&cfg))
+	assert.Equal(t, "postgres://new", cfg.DSN)
+}
+
+func TestSetLogger_LogsKeptWarnings(t *testing.T) {
+	loader, err := NewWithReader(strings.NewReader("db:\n  url: postgres://old\ndatabase:\n  pool: 8\n"))
+	require.NoError(t, err)
+
+	// Without a logger the warning is kept
+	var cfg aliasConfig
+	require.NoError(t, loader.Bind(&cfg))
+
+	var buf bytes.Buffer
+	SetLogger(loader.Sub(""), slog.New(slog.NewJSONHandler(&buf, nil)))
+	lines := logLines(t, &buf)
+	require.Len(t, lines, 2)
+	assert.Equal(t, "db.url", lines[0]["deprecated"])
+	assert.Equal(t, "database", lines[1]["deprecated"])
+
+	// Kept warnings are logged only once
+	SetLogger(loader, slog.New(slog.NewJSONHandler(&buf, nil)))
+	require.NoError(t, loader.Bind(&cfg))
+	assert.Len(t, logLines(t, &buf), 2)
+}
@@ -0,0 +1,255 @@
+package configx
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+)
+
+// Encrypted config values have the form
+//
+//	ENC[AES256_GCM,data:<base64>,iv:<base64>,tag:<base64>]
+//
+// and are decrypted with a 32-byte AES-256 key while config files are
+// loaded, before any value is decoded. The key is taken from, in order:
+//
+//  1. WithEncryptionKey() / WithEncryptionKeyFile() options
+//  2. the CONFIG_ENCRYPTION_KEY environment variable
+//  3. the file named by the CONFIG_ENCRYPTION_KEY_FILE environment variable
+//
+// Keys are given base64 or hex encoded; key files may also hold the raw 32
+// bytes. A file with encrypted values and no key fails loading.
+const (
+	encPrefix    = "ENC["
+	encAlgorithm = "AES256_GCM"
+)
+
+// ErrNoEncryptionKey is returned when a config file holds an encrypted value
+// but no decryption key is configured.
+var ErrNoEncryptionKey = errors.New("no config encryption key configured")
+
+// IsEncrypted reports whether s is an ENC[...] encrypted value.
+func IsEncrypted(s string) bool {
+	return strings.HasPrefix(s, encPrefix) && strings.HasSuffix(s, "]")
+}
+
+// EncryptValue encrypts plaintext with AES-256-GCM and returns it in the
+// ENC[AES256_GCM,data:...,iv:...,tag:...] form. key must be 32 bytes.
+func EncryptValue(key []byte, plaintext string) (string, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return "", err
+	}
+	iv := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(iv); err != nil {
+		return "", fmt.Errorf("failed to generate iv: %w", err)
+	}
+
+	sealed := gcm.Seal(nil, iv, []byte(plaintext), nil)
+	data, tag := sealed[:len(sealed)-gcm.Overhead()], sealed[len(sealed)-gcm.Overhead():]
+
+	enc := base64.StdEncoding.EncodeToString
+	return fmt.Sprintf("%s%s,data:%s,iv:%s,tag:%s]", encPrefix, encAlgorithm, enc(data), enc(iv), enc(tag)), nil
+}
+
+// DecryptValue decrypts a value produced by EncryptValue. key must be the
+// 32-byte key it was encrypted with.
+func DecryptValue(key []byte, value string) (string, error) {
+	if !IsEncrypted(value) {
+		return "", fmt.Errorf("not an encrypted value")
+	}
+
+	fields := strings.Split(strings.TrimSuffix(strings.TrimPrefix(value, encPrefix), "]"), ",")
+	if fields[0] != encAlgorithm {
+		return "", fmt.Errorf("unsupported encryption algorithm %q", fields[0])
+	}
+	parts := map[string][]byte{}
+	for _, f := range fields[1:] {
+		name, val, ok := strings.Cut(f, ":")
+		if !ok {
+			return "", fmt.Errorf("malformed encrypted value field %q", f)
+		}
+		b, err := base64.StdEncoding.DecodeString(val)
+		if err != nil {
+			return "", fmt.Errorf("malformed encrypted value field %q: %w", name, err)
+		}
+		parts[name] = b
+	}
+	for _, name := range []string{"data", "iv", "tag"} {
+		if _, ok := parts[name]; !ok {
+			return "", fmt.Errorf("encrypted value is missing %q", name)
+		}
+	}
+
+	gcm, err := newGCM(key)
+	if err != nil {
+		return "", err
+	}
+	if len(parts["iv"]) != gcm.NonceSize() {
+		return "", fmt.Errorf("invalid iv length %d", len(parts["iv"]))
+	}
+	plain, err := gcm.Open(nil, parts["iv"], append(parts["data"], parts["tag"]...), nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt value: wrong key or corrupted data")
+	}
+	return string(plain), nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	if len(key) != 32 {
+		return nil, fmt.Errorf("encryption key must be 32 bytes, got %d", len(key))
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// ParseEncryptionKey decodes a base64 or hex encoded 32-byte key.
+func ParseEncryptionKey(s string) ([]byte, error) {
+	s = strings.TrimSpace(s)
+	for _, decode := range []func(string) ([]byte, error){
+		base64.StdEncoding.DecodeString,
+		base64.RawStdEncoding.DecodeString,
+		base64.URLEncoding.DecodeString,
+		base64.RawURLEncoding.DecodeString,
+		hex.DecodeString,
+	} {
+		if key, err := decode(s); err == nil && len(key) == 32 {
+			return key, nil
+		}
+	}
+	return nil, fmt.Errorf("encryption key must be 32 bytes, base64 or hex encoded")
+}
+
+// ReadEncryptionKeyFile reads a key file holding the raw 32-byte key or its
+// base64 or hex encoding.
+func ReadEncryptionKeyFile(path string) ([]byte, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read encryption key file: %w", err)
+	}
+	if len(b) == 32 {
+		return b, nil
+	}
+	key, err := ParseEncryptionKey(string(b))
+	if err != nil {
+		return nil, fmt.Errorf("invalid encryption key file %s: %w", path, err)
+	}
+	return key, nil
+}
+
+// EncryptionKeyFromEnv returns the key named by CONFIG_ENCRYPTION_KEY or
+// CONFIG_ENCRYPTION_KEY_FILE, or ErrNoEncryptionKey when neither is set.
+func EncryptionKeyFromEnv() ([]byte, error) {
+	if s := strings.TrimSpace(os.Getenv(EnvEncryptionKey)); s != "" {
+		key, err := ParseEncryptionKey(s)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s: %w", EnvEncryptionKey, err)
+		}
+		return key, nil
+	}
+	if path := strings.TrimSpace(os.Getenv(EnvEncryptionKeyFile)); path != "" {
+		return ReadEncryptionKeyFile(path)
+	}
+	return nil, ErrNoEncryptionKey
+}
+
+// keySource resolves the decryption key the first time an encrypted value
+// is found, so loaders without encrypted values need no key.
+type keySource struct {
+	once sync.Once
+	load func() ([]byte, error)
+	key  []byte
+	err  error
+}
+
+func newKeySource(cfg *LoaderConfig) *keySource {
+	return &keySource{load: func() ([]byte, error) {
+		switch {
+		case cfg.EncryptionKey != nil:
+			if len(cfg.EncryptionKey) != 32 {
+				return nil, fmt.Errorf("encryption key must be 32 bytes, got %d", len(cfg.EncryptionKey))
+			}
+			return cfg.EncryptionKey, nil
+		case cfg.EncryptionKeyFile != "":
+			return ReadEncryptionKeyFile(cfg.EncryptionKeyFile)
+		default:
+			return EncryptionKeyFromEnv()
+		}
+	}}
+}
+
+func (k *keySource) get() ([]byte, error) {
+	k.once.Do(func() { k.key, k.err = k.load() })
+	return k.key, k.err
+}
+
+// decryptString returns s, decrypted when it is an encrypted value.
+func (k *keySource) decryptString(s string) (string, error) {
+	if !IsEncrypted(s) {
+		return s, nil
+	}
+	key, err := k.get()
+	if err != nil {
+		return "", err
+	}
+	return DecryptValue(key, s)
+}
+
+// decryptSettings decrypts encrypted values in settings in place. It
+// reports the dot-separated key of the first value that fails.
+func (k *keySource) decryptSettings(settings map[string]any) error {
+	return k.decryptValue(settings, "")
+}
+
+func (k *keySource) decryptValue(v any, path string) error {
+	switch val := v.(type) {
+	case map[string]any:
+		for name, item := range val {
+			p := joinKey(path, name)
+			if s, ok := item.(string); ok {
+				plain, err := k.decryptString(s)
+				if err != nil {
+					return fmt.Errorf("key %s: %w", p, err)
+				}
+				val[name] = plain
+				continue
+			}
+			if err := k.decryptValue(item, p); err != nil {
+				return err
+			}
+		}
+	case []any:
+		for i, item := range val {
+			p := fmt.Sprintf("%s[%d]", path, i)
+			if s, ok := item.(string); ok {
+				plain, err := k.decryptString(s)
+				if err != nil {
+					return fmt.Errorf("key %s: %w", p, err)
+				}
+				val[i] = plain
+				continue
+			}
+			if err := k.decryptValue(item, p); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func joinKey(prefix, key string) string {
+	if prefix == "" {
+		return key
+	}
+	return prefix + "." + key
+}
@@ -0,0 +1,200 @@
+package configx
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testEncryptionKey = []byte("0123456789abcdef0123456789abcdef")
+
+type secretConfig struct {
+	User     string   `mapstructure:"user"`
+	Password string   `mapstructure:"password"`
+	Port     int      `mapstructure:"port"`
+	Tokens   []string `mapstructure:"tokens"`
+}
+
+func (secretConfig) Prefix() string { return "secret" }
+
+func mustEncrypt(t *testing.T, s string) string {
+	t.Helper()
+	enc, err := EncryptValue(testEncryptionKey, s)
+	require.NoError(t, err)
+	return enc
+}
+
+func TestEncryptDecryptValue(t *testing.T) {
+	enc := mustEncrypt(t, "hunter2")
+	assert.True(t, IsEncrypted(enc))
+	assert.True(t, strings.HasPrefix(enc, "ENC[AES256_GCM,data:"))
+	assert.Contains(t, enc, ",iv:")
+	assert.Contains(t, enc, ",tag:")
+	// A fresh iv per call
+	assert.NotEqual(t, enc, mustEncrypt(t, "hunter2"))
+
+	plain, err := DecryptValue(testEncryptionKey, enc)
+	require.NoError(t, err)
+	assert.Equal(t, "hunter2", plain)
+
+	empty, err := DecryptValue(testEncryptionKey, mustEncrypt(t, ""))
+	require.NoError(t, err)
+	assert.Equal(t, "", empty)
+}
+
+func TestDecryptValue_Errors(t *testing.T) {
+	enc := mustEncrypt(t, "hunter2")
+
+	tests := []struct {
+		name  string
+		key   []byte
+		value string
+		want  string
+	}{
+		{"wrong key", []byte("ffffffffffffffffffffffffffffffff"), enc, "wrong key or corrupted data"},
+		{"short key", []byte("short"), enc, "must be 32 bytes"},
+		{"plain value", testEncryptionKey, "hunter2", "not an encrypted value"},
+		{"algorithm", testEncryptionKey, strings.Replace(enc, "AES256_GCM", "AES128_CBC", 1), "unsupported encryption algorithm"},
+		{"missing tag", testEncryptionKey, enc[:strings.Index(enc, ",tag:")] + "]", `missing "tag"`},
+		{"bad base64", testEncryptionKey, "ENC[AES256_GCM,data:!!,iv:AA==,tag:AA==]", "malformed"},
+		{"tampered", testEncryptionKey, strings.Replace(enc, "data:", "data:AAAA", 1), "wrong key or corrupted data"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := DecryptValue(tt.key, tt.value)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.want)
+		})
+	}
+}
+
+func TestParseEncryptionKey(t *testing.T) {
+	for _, s := range []string{
+		base64.StdEncoding.EncodeToString(testEncryptionKey),
+		base64.RawURLEncoding.EncodeToString(testEncryptionKey),
+		hex.EncodeToString(testEncryptionKey) + "\n",
+	} {
+		key, err := ParseEncryptionKey(s)
+		require.NoError(t, err, s)
+		assert.Equal(t, testEncryptionKey, key)
+	}
+
+	_, err := ParseEncryptionKey(base64.StdEncoding.EncodeToString([]byte("too short")))
+	assert.Error(t, err)
+}
+
+func TestLoader_DecryptsFileValues(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "base.yaml", "secret:\n  user: app\n  password: "+mustEncrypt(t, "hunter2")+
+		"\n  port: "+mustEncrypt(t, "5432")+"\n  tokens:\n    - "+mustEncrypt(t, "t1")+"\n    - plain\n")
+	t.Setenv(EnvEncryptionKey, base64.StdEncoding.EncodeToString(testEncryptionKey))
+
+	loader, err := NewE(WithConfigPaths(dir))
+	require.NoError(t, err)
+
+	var cfg secretConfig
+	require.NoError(t, loader.Bind(&cfg))
+	assert.Equal(t, secretConfig{User: "app", Password: "hunter2", Port: 5432, Tokens: []string{"t1", "plain"}}, cfg)
+	assert.Equal(t, "hunter2", loader.GetString("secret.password"))
+}
+
+func TestLoader_EncryptionKeySources(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "base.yaml", "secret:\n  password: "+mustEncrypt(t, "hunter2")+"\n")
+	keyFile := filepath.Join(dir, "config.key")
+	require.NoError(t, os.WriteFile(keyFile, testEncryptionKey, 0o600))
+
+	bind := func(t *testing.T, opts ...Option) string {
+		t.Helper()
+		loader, err := NewE(append([]Option{WithConfigPaths(dir)}, opts...)...)
+		require.NoError(t, err)
+		var cfg secretConfig
+		require.NoError(t, loader.Bind(&cfg))
+		return cfg.Password
+	}
+
+	t.Run("option", func(t *testing.T) {
+		assert.Equal(t, "hunter2", bind(t, WithEncryptionKey(testEncryptionKey)))
+	})
+	t.Run("option key file", func(t *testing.T) {
+		assert.Equal(t, "hunter2", bind(t, WithEncryptionKeyFile(keyFile)))
+	})
+	t.Run("env key file", func(t *testing.T) {
+		t.Setenv(EnvEncryptionKeyFile, keyFile)
+		assert.Equal(t, "hunter2", bind(t))
+	})
+	t.Run("option wins over env", func(t *testing.T) {
+		t.Setenv(EnvEncryptionKey, "not-a-key")
+		assert.Equal(t, "hunter2", bind(t, WithEncryptionKey(testEncryptionKey)))
+	})
+}
+
+func TestLoader_DecryptionErrors(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "base.yaml", "secret:\n  password: "+mustEncrypt(t, "hunter2")+"\n")
+
+	_, err := NewE(WithConfigPaths(dir))
+	require.ErrorIs(t, err, ErrNoEncryptionKey)
+	assert.Contains(t, err.Error(), "failed to decrypt config file")
+	assert.Contains(t, err.Error(), "key secret.password")
+
+	_, err = NewE(WithConfigPaths(dir), WithEncryptionKey([]byte("ffffffffffffffffffffffffffffffff")))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "wrong key or corrupted data")
+
+	t.Setenv(EnvEncryptionKey, "not-a-key")
+	_, err = NewE(WithConfigPaths(dir))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid "+EnvEncryptionKey)
+}
+
+func TestLoader_NoKeyNeededWithoutEncryptedValues(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "base.yaml", "secret:\n  password: plain\n")
+	t.Setenv(EnvEncryptionKey, "not-a-key")
+
+	loader, err := NewE(WithConfigPaths(dir))
+	require.NoError(t, err)
+	assert.Equal(t, "plain", loader.GetString("secret.password"))
+}
+
+func TestLoader_DecryptsDotenvValues(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, ".env", "STRATUM_SECRET_PASSWORD="+mustEncrypt(t, "from-dotenv")+"\n")
+	t.Cleanup(func() { os.Unsetenv("STRATUM_SECRET_PASSWORD") })
+
+	loader, err := NewE(WithConfigPaths(dir), WithFiles(filepath.Join(dir, ".env")), WithEncryptionKey(testEncryptionKey))
+	require.NoError(t, err)
+	// Decrypted secrets are never exported to the process environment
+	_, exported := os.LookupEnv("STRATUM_SECRET_PASSWORD")
+	assert.False(t, exported)
+	assert.Equal(t, "from-dotenv", loader.GetString("secret.password"))
+
+	var cfg secretConfig
+	require.NoError(t, loader.Bind(&cfg))
+	assert.Equal(t, "from-dotenv", cfg.Password)
+
+	// The process environment still wins
+	t.Setenv("STRATUM_SECRET_PASSWORD", "from-env")
+	cfg = secretConfig{}
+	require.NoError(t, loader.Bind(&cfg))
+	assert.Equal(t, "from-env", cfg.Password)
+}
+
+func TestNewWithReader_DecryptsValues(t *testing.T) {
+	loader, err := NewWithReader(strings.NewReader("secret:\n  password: "+mustEncrypt(t, "hunter2")+"\n"), WithEncryptionKey(testEncryptionKey))
+	require.NoError(t, err)
+
+	var cfg secretConfig
+	require.NoError(t, loader.Bind(&cfg))
+	assert.Equal(t, "hunter2", cfg.Password)
+
+	_, err = NewWithReader(strings.NewReader("secret:\n  password: " + mustEncrypt(t, "hunter2") + "\n"))
+	require.ErrorIs(t, err, ErrNoEncryptionKey)
+}
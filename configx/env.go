@@ -0,0 +1,345 @@
+package configx
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// envTokenReplacer splits struct field keys into env var name tokens.
+var envTokenReplacer = strings.NewReplacer(".", "_", "-", "_")
+
+// discoverEnv adds env vars below prefix to settings when viper cannot see
+// them. AutomaticEnv only overrides keys already present in a config file, so
+// new map entries (STRATUM_DB_DATABASES_REPLICA_HOST) and list elements
+// (STRATUM_KAFKA_BROKERS_0) are resolved here by matching the underscore
+// separated name against the structure of the target type.
+//
+// A list element may replace an existing element or append one; an index
+// that would leave a gap, such as BROKERS_5 for a list of two, is an error.
+func (l *viperLoader) discoverEnv(settings map[string]any, prefix string, target reflect.Type) error {
+	base := l.envName(prefix) + "_"
+
+	names := []string{}
+	for _, kv := range os.Environ() {
+		if name, _, ok := strings.Cut(kv, "="); ok && strings.HasPrefix(name, base) {
+			names = append(names, name)
+		}
+	}
+	for name := range l.dotenv {
+		if strings.HasPrefix(name, base) {
+			names = append(names, name)
+		}
+	}
+	// Sort so that e.g. KAFKA_BROKERS is applied before KAFKA_BROKERS_0, and
+	// KAFKA_BROKERS_2 before KAFKA_BROKERS_10
+	slices.SortFunc(names, compareEnvNames)
+	names = slices.Compact(names)
+
+	for _, name := range names {
+		path, ok := resolveEnvPath(target, strings.Split(strings.TrimPrefix(name, base), "_"))
+		if !ok || len(path) == 0 {
+			continue
+		}
+		val, _ := l.lookupEnv(name)
+		if _, err := setPath(settings, path, val); err != nil {
+			return fmt.Errorf("env var %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// compareEnvNames orders env var names token by token, comparing numeric
+// tokens as numbers.
+func compareEnvNames(a, b string) int {
+	at, bt := strings.Split(a, "_"), strings.Split(b, "_")
+	for i := 0; i < len(at) && i < len(bt); i++ {
+		an, aerr := strconv.Atoi(at[i])
+		bn, berr := strconv.Atoi(bt[i])
+		if aerr == nil && berr == nil {
+			if c := an - bn; c != 0 {
+				return c
+			}
+			continue
+		}
+		if c := strings.Compare(at[i], bt[i]); c != 0 {
+			return c
+		}
+	}
+	return len(at) - len(bt)
+}
+
+// applyEnvTags sets fields tagged with env:"VAR1,VAR2" from the first listed
+// env var that is set, overriding file and prefixed env values. Fields tagged
+// envonly:"true" must not be set in config files.
+//
+// Example:
+//
+//	type DBConfig struct {
+//	    DSN string `mapstructure:"dsn" env:"DATABASE_URL,DB_DSN" envonly:"true"`
+//	}
+func (l *viperLoader) applyEnvTags(settings map[string]any, prefix string, target reflect.Type) error {
+	return walkFields(target, nil, func(path []any, f reflect.StructField) error {
+		if envOnly, _ := strconv.ParseBool(f.Tag.Get("envonly")); envOnly {
+			key := prefix
+			for _, p := range path {
+				key += "." + p.(string)
+			}
+			if l.v.InConfig(key) {
+				return fmt.Errorf("config key '%s' is env-only and must not be set in config files", key)
+			}
+		}
+
+		for name := range strings.SplitSeq(f.Tag.Get("env"), ",") {
+			if name = strings.TrimSpace(name); name == "" {
+				continue
+			}
+			if val, ok := l.lookupEnv(name); ok {
+				if _, err := setPath(settings, path, val); err != nil {
+					return fmt.Errorf("env var %s: %w", name, err)
+				}
+				break
+			}
+		}
+		return nil
+	})
+}
+
+// walkFields calls fn for every addressable struct field below t, descending
+// into nested and squashed structs but not into maps or lists. Recursive
+// types are visited once per path.
+func walkFields(t reflect.Type, path []any, fn func(path []any, f reflect.StructField) error) error {
+	return walkFieldsSeen(t, path, nil, fn)
+}
+
+func walkFieldsSeen(t reflect.Type, path []any, seen []reflect.Type, fn func(path []any, f reflect.StructField) error) error {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if !hasExportedFields(t) || slices.Contains(seen, t) {
+		return nil
+	}
+	seen = append(seen, t)
+
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name, squash := fieldKey(f)
+		if name == "-" || (!f.IsExported() && !squash) {
+			continue
+		}
+
+		fieldPath := slices.Concat(path, []any{name})
+		if squash {
+			fieldPath = path
+		} else if err := fn(fieldPath, f); err != nil {
+			return err
+		}
+		if err := walkFieldsSeen(f.Type, fieldPath, seen, fn); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// lookupEnv looks name up in the process environment, then in the values
+// read from dotenv files, which are never exported to the process.
+func (l *viperLoader) lookupEnv(name string) (string, bool) {
+	if val, ok := os.LookupEnv(name); ok {
+		return val, true
+	}
+	val, ok := l.dotenv[name]
+	return val, ok
+}
+
+// dotenvValue returns the dotenv value standing in for the env vars of a
+// full key, its automatic name and any BindEnv names, when none of them is
+// set in the process environment.
+func (l *viperLoader) dotenvValue(key string) (string, bool) {
+	if len(l.dotenv) == 0 {
+		return "", false
+	}
+	names := append([]string{l.envName(key)}, l.boundKeys[key]...)
+	for _, name := range names {
+		if _, ok := os.LookupEnv(name); ok {
+			return "", false
+		}
+	}
+	for _, name := range names {
+		if val, ok := l.dotenv[name]; ok {
+			return val, true
+		}
+	}
+	return "", false
+}
+
+// get returns the value of a full key like viper's Get, with dotenv values
+// ranking as env vars.
+func (l *viperLoader) get(key string) any {
+	if val, ok := l.dotenvValue(key); ok {
+		return val
+	}
+	return l.v.Get(key)
+}
+
+// isSet reports whether a full key has a value, including from dotenv files.
+func (l *viperLoader) isSet(key string) bool {
+	if _, ok := l.dotenvValue(key); ok {
+		return true
+	}
+	return l.v.IsSet(key)
+}
+
+// envName converts a full config key into its prefixed env var name.
+func (l *viperLoader) envName(key string) string {
+	name := strings.ToUpper(l.envReplacer.Replace(key))
+	if p := strings.TrimSpace(l.envPrefix); p != "" {
+		name = strings.ToUpper(p) + "_" + name
+	}
+	return name
+}
+
+// resolveEnvPath matches env var name tokens against t and returns the
+// settings path they address: map keys and struct fields as strings, list
+// indexes as ints. Map keys take the fewest tokens that leave a valid path.
+func resolveEnvPath(t reflect.Type, tokens []string) ([]any, bool) {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	if len(tokens) == 0 {
+		// Only leaf values can be set from a single env var
+		return nil, t.Kind() != reflect.Map && !hasExportedFields(t)
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		return resolveStructEnvPath(t, tokens)
+
+	case reflect.Map:
+		if t.Key().Kind() != reflect.String {
+			return nil, false
+		}
+		for i := 1; i <= len(tokens); i++ {
+			if rest, ok := resolveEnvPath(t.Elem(), tokens[i:]); ok {
+				key := strings.ToLower(strings.Join(tokens[:i], "_"))
+				return append([]any{key}, rest...), true
+			}
+		}
+		return nil, false
+
+	case reflect.Slice, reflect.Array:
+		idx, err := strconv.Atoi(tokens[0])
+		if err != nil || idx < 0 {
+			return nil, false
+		}
+		rest, ok := resolveEnvPath(t.Elem(), tokens[1:])
+		if !ok {
+			return nil, false
+		}
+		return append([]any{idx}, rest...), true
+
+	default:
+		return nil, false
+	}
+}
+
+func resolveStructEnvPath(t reflect.Type, tokens []string) ([]any, bool) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name, squash := fieldKey(f)
+		if name == "-" || (!f.IsExported() && !squash) {
+			continue
+		}
+		if squash {
+			if path, ok := resolveEnvPath(f.Type, tokens); ok {
+				return path, true
+			}
+			continue
+		}
+
+		fieldTokens := strings.Split(strings.ToUpper(envTokenReplacer.Replace(name)), "_")
+		if len(tokens) < len(fieldTokens) || !slices.Equal(tokens[:len(fieldTokens)], fieldTokens) {
+			continue
+		}
+		if rest, ok := resolveEnvPath(f.Type, tokens[len(fieldTokens):]); ok {
+			return append([]any{name}, rest...), true
+		}
+	}
+	return nil, false
+}
+
+// fieldKey returns the settings key mapstructure uses for a struct field and
+// whether the field is squashed into its parent.
+func fieldKey(f reflect.StructField) (string, bool) {
+	tag := f.Tag.Get("mapstructure")
+	name, opts, _ := strings.Cut(tag, ",")
+	squash := slices.Contains(strings.Split(opts, ","), "squash")
+	if name == "" {
+		name = f.Name
+	}
+	return strings.ToLower(name), squash
+}
+
+// hasExportedFields reports whether t is a struct with fields that config
+// keys can address, which excludes opaque structs such as time.Time.
+func hasExportedFields(t reflect.Type) bool {
+	if t.Kind() != reflect.Struct || t == reflect.TypeOf(time.Time{}) {
+		return false
+	}
+	for i := 0; i < t.NumField(); i++ {
+		if t.Field(i).IsExported() {
+			return true
+		}
+	}
+	return false
+}
+
+// setPath stores value at path inside container, creating maps for string
+// path elements and lists for int elements, and returns the updated
+// container. A list index may address an existing element or the next one;
+// larger indexes are an error, so no element is left unset.
+func setPath(container any, path []any, value any) (any, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+
+	switch key := path[0].(type) {
+	case string:
+		m, ok := container.(map[string]any)
+		if !ok {
+			m = make(map[string]any)
+		}
+		val, err := setPath(m[key], path[1:], value)
+		if err != nil {
+			return nil, err
+		}
+		m[key] = val
+		return m, nil
+
+	case int:
+		var list []any
+		if rv := reflect.ValueOf(container); rv.Kind() == reflect.Slice {
+			for i := 0; i < rv.Len(); i++ {
+				list = append(list, rv.Index(i).Interface())
+			}
+		}
+		if key > len(list) {
+			return nil, fmt.Errorf("list index %d skips elements: the list has %d, so the next index is %d", key, len(list), len(list))
+		}
+		if key == len(list) {
+			list = append(list, nil)
+		}
+		val, err := setPath(list[key], path[1:], value)
+		if err != nil {
+			return nil, err
+		}
+		list[key] = val
+		return list, nil
+	}
+
+	return container, nil
+}
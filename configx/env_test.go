@@ -0,0 +1,171 @@
+package configx
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type envDatabase struct {
+	Host    string        `mapstructure:"host"`
+	Port    int           `mapstructure:"port"`
+	MaxOpen int           `mapstructure:"max_open"`
+	Timeout time.Duration `mapstructure:"timeout"`
+}
+
+type envBroker struct {
+	Host string `mapstructure:"host"`
+	Port int    `mapstructure:"port"`
+}
+
+type envCommon struct {
+	Region string `mapstructure:"region"`
+}
+
+type envDiscoveryConfig struct {
+	envCommon `mapstructure:",squash"`
+	Databases map[string]envDatabase  `mapstructure:"databases"`
+	Labels    map[string]string       `mapstructure:"labels"`
+	Brokers   []string                `mapstructure:"brokers"`
+	Servers   []envBroker             `mapstructure:"servers"`
+	Pools     map[string]*envDatabase `mapstructure:"pools"`
+	Name      string                  `mapstructure:"name"`
+}
+
+func (envDiscoveryConfig) Prefix() string { return "db" }
+
+const envDiscoveryYAML = `
+db:
+  name: file
+  databases:
+    primary:
+      host: primary-host
+      port: 5432
+  brokers:
+    - file-0
+    - file-1
+  servers:
+    - host: s0
+      port: 1
+`
+
+func TestBind_EnvAddsMapEntries(t *testing.T) {
+	t.Setenv("STRATUM_DB_DATABASES_REPLICA_HOST", "replica-host")
+	t.Setenv("STRATUM_DB_DATABASES_REPLICA_PORT", "5433")
+	t.Setenv("STRATUM_DB_DATABASES_REPLICA_MAX_OPEN", "10")
+	t.Setenv("STRATUM_DB_DATABASES_READ_ONLY_TIMEOUT", "5s")
+	t.Setenv("STRATUM_DB_DATABASES_PRIMARY_PORT", "6000")
+
+	var cfg envDiscoveryConfig
+	require.NoError(t, newTestLoader(t, envDiscoveryYAML).Bind(&cfg))
+
+	require.Contains(t, cfg.Databases, "replica")
+	assert.Equal(t, envDatabase{Host: "replica-host", Port: 5433, MaxOpen: 10}, cfg.Databases["replica"])
+	// Multi-token map keys are kept whole
+	assert.Equal(t, 5*time.Second, cfg.Databases["read_only"].Timeout)
+	// Existing entries keep file values and take env overrides
+	assert.Equal(t, "primary-host", cfg.Databases["primary"].Host)
+	assert.Equal(t, 6000, cfg.Databases["primary"].Port)
+}
+
+func TestBind_EnvScalarMapAndPointers(t *testing.T) {
+	t.Setenv("STRATUM_DB_LABELS_TEAM_NAME", "platform")
+	t.Setenv("STRATUM_DB_POOLS_MAIN_HOST", "pool-host")
+
+	var cfg envDiscoveryConfig
+	require.NoError(t, newTestLoader(t, envDiscoveryYAML).Bind(&cfg))
+
+	assert.Equal(t, map[string]string{"team_name": "platform"}, cfg.Labels)
+	require.NotNil(t, cfg.Pools["main"])
+	assert.Equal(t, "pool-host", cfg.Pools["main"].Host)
+}
+
+func TestBind_EnvListElements(t *testing.T) {
+	t.Setenv("STRATUM_DB_BROKERS_1", "env-1")
+	t.Setenv("STRATUM_DB_BROKERS_2", "env-2")
+	t.Setenv("STRATUM_DB_SERVERS_0_PORT", "10")
+	t.Setenv("STRATUM_DB_SERVERS_1_HOST", "s1")
+
+	var cfg envDiscoveryConfig
+	require.NoError(t, newTestLoader(t, envDiscoveryYAML).Bind(&cfg))
+
+	assert.Equal(t, []string{"file-0", "env-1", "env-2"}, cfg.Brokers)
+	assert.Equal(t, []envBroker{{Host: "s0", Port: 10}, {Host: "s1"}}, cfg.Servers)
+}
+
+func TestBind_EnvListIndexOrder(t *testing.T) {
+	for i := 2; i <= 10; i++ {
+		t.Setenv("STRATUM_DB_BROKERS_"+strconv.Itoa(i), "env-"+strconv.Itoa(i))
+	}
+
+	var cfg envDiscoveryConfig
+	require.NoError(t, newTestLoader(t, envDiscoveryYAML).Bind(&cfg))
+	require.Len(t, cfg.Brokers, 11)
+	assert.Equal(t, "env-10", cfg.Brokers[10])
+}
+
+func TestBind_EnvListIndexGap(t *testing.T) {
+	for _, name := range []string{"STRATUM_DB_BROKERS_3", "STRATUM_DB_BROKERS_1000000000", "STRATUM_DB_SERVERS_2_HOST"} {
+		t.Run(name, func(t *testing.T) {
+			t.Setenv(name, "x")
+			var cfg envDiscoveryConfig
+			err := newTestLoader(t, envDiscoveryYAML).Bind(&cfg)
+			assert.ErrorContains(t, err, "env var "+name+": list index")
+			assert.ErrorContains(t, err, "skips elements")
+		})
+	}
+}
+
+func TestBind_EnvSquashedAndPrefix(t *testing.T) {
+	t.Setenv("MYAPP_DB_REGION", "eu")
+	t.Setenv("MYAPP_DB_DATABASES_X_HOST", "x")
+	t.Setenv("STRATUM_DB_DATABASES_Y_HOST", "ignored")
+
+	var cfg envDiscoveryConfig
+	require.NoError(t, newTestLoader(t, envDiscoveryYAML, WithEnvPrefix("MYAPP")).Bind(&cfg))
+
+	assert.Equal(t, "eu", cfg.Region)
+	assert.Contains(t, cfg.Databases, "x")
+	assert.NotContains(t, cfg.Databases, "y")
+}
+
+func TestBind_EnvUnknownIgnored(t *testing.T) {
+	t.Setenv("STRATUM_DB_UNKNOWN_FIELD", "x")
+	t.Setenv("STRATUM_DB_BROKERS_NOTANINDEX", "x")
+
+	var cfg envDiscoveryConfig
+	require.NoError(t, newTestLoader(t, envDiscoveryYAML).Bind(&cfg))
+	assert.Equal(t, "file", cfg.Name)
+	assert.Len(t, cfg.Databases, 1)
+}
+
+func TestResolveEnvPath(t *testing.T) {
+	tests := []struct {
+		name   string
+		tokens string
+		want   []any
+		ok     bool
+	}{
+		{"struct field", "NAME", []any{"name"}, true},
+		{"map struct entry", "DATABASES_A_B_HOST", []any{"databases", "a_b", "host"}, true},
+		{"field with underscore", "DATABASES_A_MAX_OPEN", []any{"databases", "a", "max_open"}, true},
+		{"list index", "SERVERS_3_HOST", []any{"servers", 3, "host"}, true},
+		{"negative index", "BROKERS_-1", nil, false},
+		{"map without key", "DATABASES", nil, false},
+		{"unknown", "NOPE", nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := resolveEnvPath(reflect.TypeOf(envDiscoveryConfig{}), strings.Split(tt.tokens, "_"))
+			assert.Equal(t, tt.ok, ok)
+			if tt.ok {
+				assert.Equal(t, tt.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,11 @@
+package configx
+
+import "errors"
+
+// ErrConfigNotFound is returned when a configuration key is not set in any
+// config file, environment variable or BindEnv binding.
+var ErrConfigNotFound = errors.New("config key not found")
+
+// ErrDeprecatedKey is returned by Bind when a deprecated key (see KeyAliaser)
+// is set and the loader was created with WithStrictDeprecations.
+var ErrDeprecatedKey = errors.New("deprecated config key")
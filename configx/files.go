@@ -0,0 +1,291 @@
+package configx
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/spf13/viper"
+	"github.com/subosito/gotenv"
+)
+
+// Supported configuration file formats.
+const (
+	FormatYAML   = "yaml"
+	FormatJSON   = "json"
+	FormatTOML   = "toml"
+	FormatHCL    = "hcl"
+	FormatDotenv = "dotenv"
+)
+
+// ConfigFile describes an explicit configuration file layered by the Loader.
+type ConfigFile struct {
+	// Path is the file location on disk.
+	Path string
+	// Format overrides the format derived from the file extension.
+	Format string
+	// Optional files are skipped when missing; required files fail loading.
+	Optional bool
+}
+
+// formatFromPath derives the configuration format from a file name.
+// Both "app.env" and a bare ".env" map to the dotenv format.
+func formatFromPath(path string) (string, error) {
+	base := strings.ToLower(filepath.Base(path))
+	if base == ".env" || strings.HasPrefix(base, ".env.") {
+		return FormatDotenv, nil
+	}
+
+	switch ext := strings.TrimPrefix(filepath.Ext(base), "."); ext {
+	case "yaml", "yml":
+		return FormatYAML, nil
+	case "json":
+		return FormatJSON, nil
+	case "toml":
+		return FormatTOML, nil
+	case "hcl", "tfvars":
+		return FormatHCL, nil
+	case "env", "dotenv":
+		return FormatDotenv, nil
+	default:
+		return "", fmt.Errorf("unsupported config file format %q for %s", ext, path)
+	}
+}
+
+// normalizeFormat maps format aliases to the names used by viper codecs.
+func normalizeFormat(format string) string {
+	switch f := strings.ToLower(strings.TrimSpace(format)); f {
+	case "yml":
+		return FormatYAML
+	case "env":
+		return FormatDotenv
+	default:
+		return f
+	}
+}
+
+// IncludeKey is the top-level key a config file uses to compose other files.
+// Included paths are resolved relative to the including file and merged
+// before it, so the including file overrides what it includes.
+//
+//	include:
+//	  - common/logging.yaml
+//	  - common/db.yaml
+const IncludeKey = "include"
+
+// searchExts lists the extensions tried, in order, when looking up a config
+// file by base name in the config paths. Extensions without a codec, such as
+// hcl unless one is registered with WithCodec, are skipped.
+var searchExts = []string{"json", "toml", "yaml", "yml", "hcl"}
+
+// findConfigFile returns the first file named name.{ext} in the given paths,
+// or "" when there is none. Paths are searched in order, then extensions.
+func findConfigFile(paths []string, name string, exts []string) string {
+	for _, dir := range paths {
+		if dir = strings.TrimSpace(dir); dir == "" {
+			continue
+		}
+		for _, ext := range exts {
+			path := filepath.Join(dir, name+"."+ext)
+			if info, err := os.Stat(path); err == nil && !info.IsDir() {
+				return path
+			}
+		}
+	}
+	return ""
+}
+
+// fileMerger layers config files into a viper instance, resolving includes
+// and recording the order in which files were merged.
+type fileMerger struct {
+	v      *viper.Viper
+	codecs viper.CodecRegistry
+	// keys decrypts ENC[...] values before they are merged.
+	keys *keySource
+	// stack holds the absolute paths of files currently being merged, used to
+	// detect include cycles.
+	stack []string
+	// loaded lists merged files in load order.
+	loaded []string
+	// env holds the values of dotenv files, first file wins.
+	env map[string]string
+}
+
+// mergeSearchPath merges the config file with the given base name found in
+// the config paths. A missing file is not an error, but a file that exists
+// and cannot be parsed is.
+func (m *fileMerger) mergeSearchPath(paths []string, name string) error {
+	path := findConfigFile(paths, name, m.searchExts())
+	if path == "" {
+		return nil
+	}
+	return m.merge(ConfigFile{Path: path})
+}
+
+// searchExts returns the entries of searchExts that have a codec.
+func (m *fileMerger) searchExts() []string {
+	var exts []string
+	for _, ext := range searchExts {
+		if _, err := m.codecs.Decoder(ext); err == nil {
+			exts = append(exts, ext)
+		}
+	}
+	return exts
+}
+
+// mergeFragments merges the *.yaml and *.yml files in the conf.d directory of
+// each config path. Paths are visited in order and the files of each directory
+// in lexical order. Missing directories are skipped.
+func (m *fileMerger) mergeFragments(paths []string) error {
+	for _, dir := range paths {
+		if dir = strings.TrimSpace(dir); dir == "" {
+			continue
+		}
+		entries, err := os.ReadDir(filepath.Join(dir, FragmentDir))
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				continue
+			}
+			return fmt.Errorf("failed to read config fragments in %s: %w", filepath.Join(dir, FragmentDir), err)
+		}
+
+		// ReadDir sorts entries by file name
+		for _, entry := range entries {
+			name := entry.Name()
+			ext := strings.ToLower(filepath.Ext(name))
+			if strings.HasPrefix(name, ".") || (ext != ".yaml" && ext != ".yml") {
+				continue
+			}
+			path := filepath.Join(dir, FragmentDir, name)
+			// Stat follows symlinks, as used by Kubernetes ConfigMap mounts
+			if info, err := os.Stat(path); err != nil || info.IsDir() {
+				continue
+			}
+			if err := m.merge(ConfigFile{Path: path}); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// merge layers a single file on top of the settings merged so far.
+//
+// Dotenv files are not merged as configuration keys. Their entries are kept
+// by the loader and rank as env vars below the process environment, which is
+// never modified: child processes and /proc/<pid>/environ do not see them.
+func (m *fileMerger) merge(file ConfigFile) error {
+	format := normalizeFormat(file.Format)
+	if format == "" {
+		var err error
+		if format, err = formatFromPath(file.Path); err != nil {
+			return err
+		}
+	}
+
+	abs, err := filepath.Abs(file.Path)
+	if err != nil {
+		return fmt.Errorf("failed to resolve config file %s: %w", file.Path, err)
+	}
+	if slices.Contains(m.stack, abs) {
+		return fmt.Errorf("config include cycle: %s", strings.Join(append(m.stack, abs), " -> "))
+	}
+
+	f, err := os.Open(file.Path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			if file.Optional {
+				return nil
+			}
+			return fmt.Errorf("required config file %s not found: %w", file.Path, err)
+		}
+		return fmt.Errorf("failed to open config file %s: %w", file.Path, err)
+	}
+	defer f.Close()
+
+	if format == FormatDotenv {
+		env, err := gotenv.StrictParse(f)
+		if err != nil {
+			return fmt.Errorf("failed to parse config file %s: %w", file.Path, err)
+		}
+		for k, val := range env {
+			if _, exists := os.LookupEnv(k); exists {
+				continue
+			}
+			if _, exists := m.env[k]; exists {
+				continue
+			}
+			if val, err = m.keys.decryptString(val); err != nil {
+				return fmt.Errorf("failed to decrypt config file %s: key %s: %w", file.Path, k, err)
+			}
+			if m.env == nil {
+				m.env = make(map[string]string)
+			}
+			m.env[k] = val
+		}
+		m.loaded = append(m.loaded, file.Path)
+		return nil
+	}
+
+	if _, err := m.codecs.Decoder(format); err != nil {
+		return fmt.Errorf("config file %s: format %q needs a codec registered with WithCodec", file.Path, format)
+	}
+
+	fv := viper.NewWithOptions(viper.WithCodecRegistry(m.codecs))
+	fv.SetConfigType(format)
+	if err := fv.ReadConfig(f); err != nil {
+		return fmt.Errorf("failed to parse config file %s: %w", file.Path, err)
+	}
+
+	settings := fv.AllSettings()
+	includes, err := includePaths(settings[IncludeKey])
+	if err != nil {
+		return fmt.Errorf("invalid %s in config file %s: %w", IncludeKey, file.Path, err)
+	}
+	delete(settings, IncludeKey)
+
+	if err := m.keys.decryptSettings(settings); err != nil {
+		return fmt.Errorf("failed to decrypt config file %s: %w", file.Path, err)
+	}
+
+	m.stack = append(m.stack, abs)
+	for _, inc := range includes {
+		if !filepath.IsAbs(inc) {
+			inc = filepath.Join(filepath.Dir(file.Path), inc)
+		}
+		if err := m.merge(ConfigFile{Path: inc}); err != nil {
+			return err
+		}
+	}
+	m.stack = m.stack[:len(m.stack)-1]
+
+	m.loaded = append(m.loaded, file.Path)
+	return m.v.MergeConfigMap(settings)
+}
+
+// includePaths converts the value of an include directive, either a single
+// path or a list of paths, into a slice.
+func includePaths(raw any) ([]string, error) {
+	switch val := raw.(type) {
+	case nil:
+		return nil, nil
+	case string:
+		return []string{val}, nil
+	case []any:
+		paths := make([]string, 0, len(val))
+		for _, item := range val {
+			s, ok := item.(string)
+			if !ok {
+				return nil, fmt.Errorf("expected path string, got %T", item)
+			}
+			paths = append(paths, s)
+		}
+		return paths, nil
+	default:
+		return nil, fmt.Errorf("expected path or list of paths, got %T", raw)
+	}
+}
@@ -0,0 +1,239 @@
+package configx
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type filesTestConfig struct {
+	Host string `mapstructure:"host"`
+	Port int    `mapstructure:"port"`
+	Name string `mapstructure:"name"`
+}
+
+func (filesTestConfig) Prefix() string { return "app" }
+
+// upperCodec decodes "key=value" lines under the app prefix. It stands in for
+// an HCL codec, which is not bundled with viper.
+type upperCodec struct{}
+
+func (upperCodec) Encode(map[string]any) ([]byte, error) { return nil, nil }
+
+func (upperCodec) Decode(b []byte, v map[string]any) error {
+	app := map[string]any{}
+	for _, line := range strings.Split(strings.TrimSpace(string(b)), "\n") {
+		k, val, _ := strings.Cut(line, "=")
+		app[k] = strings.ToUpper(val)
+	}
+	v["app"] = app
+	return nil
+}
+
+func writeFile(t *testing.T, dir, name, content string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	return path
+}
+
+func TestFormatFromPath(t *testing.T) {
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"a.yaml", FormatYAML},
+		{"a.YML", FormatYAML},
+		{"a.json", FormatJSON},
+		{"a.toml", FormatTOML},
+		{"a.hcl", FormatHCL},
+		{"a.env", FormatDotenv},
+		{".env", FormatDotenv},
+		{"/etc/app/.env.local", FormatDotenv},
+	}
+	for _, tt := range tests {
+		got, err := formatFromPath(tt.path)
+		require.NoError(t, err, tt.path)
+		assert.Equal(t, tt.want, got, tt.path)
+	}
+
+	_, err := formatFromPath("a.txt")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "unsupported config file format")
+}
+
+func TestWithFiles_LayersInOrder(t *testing.T) {
+	dir := t.TempDir()
+	yamlFile := writeFile(t, dir, "a.yaml", "app:\n  host: yaml-host\n  port: 1000\n  name: yaml\n")
+	jsonFile := writeFile(t, dir, "b.json", `{"app": {"port": 2000}}`)
+	tomlFile := writeFile(t, dir, "c.toml", "[app]\nname = \"toml\"\n")
+
+	loader := New(WithConfigPaths(dir), WithFiles(yamlFile, jsonFile, tomlFile))
+
+	var cfg filesTestConfig
+	require.NoError(t, loader.Bind(&cfg))
+	assert.Equal(t, "yaml-host", cfg.Host)
+	assert.Equal(t, 2000, cfg.Port)
+	assert.Equal(t, "toml", cfg.Name)
+}
+
+func TestWithFiles_OverridesSearchPathFiles(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "base.yaml", "app:\n  host: base\n  port: 1\n")
+	extra := writeFile(t, dir, "extra.yaml", "app:\n  port: 2\n")
+
+	loader := New(WithConfigPaths(dir), WithFiles(extra))
+
+	var cfg filesTestConfig
+	require.NoError(t, loader.Bind(&cfg))
+	assert.Equal(t, "base", cfg.Host)
+	assert.Equal(t, 2, cfg.Port)
+}
+
+func TestWithFiles_RequiredMissing(t *testing.T) {
+	dir := t.TempDir()
+	missing := filepath.Join(dir, "missing.yaml")
+
+	loader := New(WithConfigPaths(dir), WithFiles(missing))
+
+	var cfg filesTestConfig
+	err := loader.Bind(&cfg)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "required config file")
+	assert.Contains(t, err.Error(), missing)
+}
+
+func TestWithOptionalFiles_MissingIgnored(t *testing.T) {
+	dir := t.TempDir()
+	present := writeFile(t, dir, "present.yaml", "app:\n  port: 42\n")
+
+	loader := New(
+		WithConfigPaths(dir),
+		WithOptionalFiles(filepath.Join(dir, "missing.yaml"), present),
+	)
+
+	var cfg filesTestConfig
+	require.NoError(t, loader.Bind(&cfg))
+	assert.Equal(t, 42, cfg.Port)
+}
+
+func TestWithOptionalFiles_ParseErrorReported(t *testing.T) {
+	dir := t.TempDir()
+	broken := writeFile(t, dir, "broken.json", `{"app": `)
+
+	loader := New(WithConfigPaths(dir), WithOptionalFiles(broken))
+
+	var cfg filesTestConfig
+	err := loader.Bind(&cfg)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), broken)
+}
+
+func TestWithFiles_Dotenv(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "base.yaml", "app:\n  host: file-host\n  port: 1\n")
+	envFile := writeFile(t, dir, ".env", "STRATUM_APP_HOST=dotenv-host\nSTRATUM_APP_PORT=7\n")
+	localFile := writeFile(t, dir, ".env.local", "STRATUM_APP_HOST=local-host\n")
+
+	// Variables already present in the environment win over the .env file
+	t.Setenv("STRATUM_APP_PORT", "9")
+
+	loader := New(WithConfigPaths(dir), WithFiles(envFile, localFile))
+
+	var cfg filesTestConfig
+	require.NoError(t, loader.Bind(&cfg))
+	assert.Equal(t, "dotenv-host", cfg.Host)
+	assert.Equal(t, 9, cfg.Port)
+	assert.Equal(t, "dotenv-host", loader.GetString("app.host"))
+
+	// The process environment is left alone, so other loaders do not see
+	// the values
+	_, exported := os.LookupEnv("STRATUM_APP_HOST")
+	assert.False(t, exported)
+	other := New(WithConfigPaths(dir))
+	assert.Equal(t, "file-host", other.GetString("app.host"))
+}
+
+func TestWithFiles_DotenvBindEnv(t *testing.T) {
+	dir := t.TempDir()
+	envFile := writeFile(t, dir, ".env", "DATABASE_URL=postgres://dotenv\nSTRATUM_APP_PORT=7\n")
+
+	loader := New(WithConfigPaths(dir), WithFiles(envFile))
+	require.NoError(t, loader.BindEnv("app.host", "DATABASE_URL"))
+
+	var cfg filesTestConfig
+	require.NoError(t, loader.Bind(&cfg))
+	assert.Equal(t, "postgres://dotenv", cfg.Host)
+	assert.Equal(t, 7, cfg.Port)
+	assert.Equal(t, 7, loader.GetInt("app.port"))
+	assert.True(t, loader.IsSet("app.port"))
+}
+
+func TestWithCodec(t *testing.T) {
+	dir := t.TempDir()
+	hclFile := writeFile(t, dir, "app.hcl", "host=codec-host\n")
+
+	t.Run("without codec", func(t *testing.T) {
+		loader := New(WithConfigPaths(dir), WithFiles(hclFile))
+
+		var cfg filesTestConfig
+		err := loader.Bind(&cfg)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), `format "hcl" needs a codec registered with WithCodec`)
+	})
+
+	t.Run("with codec", func(t *testing.T) {
+		loader := New(WithConfigPaths(dir), WithCodec(FormatHCL, upperCodec{}), WithFiles(hclFile))
+
+		var cfg filesTestConfig
+		require.NoError(t, loader.Bind(&cfg))
+		assert.Equal(t, "CODEC-HOST", cfg.Host)
+	})
+}
+
+func TestSearchPath_HCLNeedsCodec(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "base.hcl", "host=codec-host\n")
+
+	// Without a codec a stray base.hcl is not looked up
+	var cfg filesTestConfig
+	require.NoError(t, New(WithConfigPaths(dir)).Bind(&cfg))
+	assert.Empty(t, cfg.Host)
+
+	require.NoError(t, New(WithConfigPaths(dir), WithCodec(FormatHCL, upperCodec{})).Bind(&cfg))
+	assert.Equal(t, "CODEC-HOST", cfg.Host)
+}
+
+func TestNewWithReader_WithFormat(t *testing.T) {
+	t.Run("json", func(t *testing.T) {
+		loader, err := NewWithReader(strings.NewReader(`{"app": {"host": "json-host", "port": 8080}}`), WithFormat(FormatJSON))
+		require.NoError(t, err)
+
+		var cfg filesTestConfig
+		require.NoError(t, loader.Bind(&cfg))
+		assert.Equal(t, "json-host", cfg.Host)
+		assert.Equal(t, 8080, cfg.Port)
+	})
+
+	t.Run("toml", func(t *testing.T) {
+		loader, err := NewWithReader(strings.NewReader("[app]\nport = 9090\n"), WithFormat("toml"))
+		require.NoError(t, err)
+
+		var cfg filesTestConfig
+		require.NoError(t, loader.Bind(&cfg))
+		assert.Equal(t, 9090, cfg.Port)
+	})
+
+	t.Run("yml alias", func(t *testing.T) {
+		loader, err := NewWithReader(strings.NewReader("app:\n  port: 1\n"), WithFormat("yml"))
+		require.NoError(t, err)
+
+		var cfg filesTestConfig
+		require.NoError(t, loader.Bind(&cfg))
+		assert.Equal(t, 1, cfg.Port)
+	})
+}
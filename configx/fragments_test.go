@@ -0,0 +1,80 @@
+package configx
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFragments_MergedBetweenBaseAndOverlay(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.Mkdir(filepath.Join(dir, FragmentDir), 0o755))
+	writeFile(t, dir, "base.yaml", "app:\n  host: base\n  port: 1\n  region: base\n")
+	writeFile(t, dir, "conf.d/20-net.yaml", "app:\n  host: fragment-20\n  port: 20\n")
+	writeFile(t, dir, "conf.d/10-net.yml", "app:\n  host: fragment-10\n  region: fragment\n")
+	writeFile(t, dir, "conf.d/README.md", "not config")
+	writeFile(t, dir, "conf.d/.hidden.yaml", "app:\n  host: hidden\n")
+	writeFile(t, dir, "prod.yaml", "app:\n  port: 80\n")
+	t.Setenv("APP_ENV", "prod")
+
+	loader, err := NewE(WithConfigPaths(dir))
+	require.NoError(t, err)
+
+	var cfg profileConfig
+	require.NoError(t, loader.Bind(&cfg))
+	assert.Equal(t, "fragment-20", cfg.Host)
+	assert.Equal(t, "fragment", cfg.Region)
+	assert.Equal(t, 80, cfg.Port)
+
+	assert.Equal(t, []string{
+		filepath.Join(dir, "base.yaml"),
+		filepath.Join(dir, "conf.d/10-net.yml"),
+		filepath.Join(dir, "conf.d/20-net.yaml"),
+		filepath.Join(dir, "prod.yaml"),
+	}, loader.LoadedFiles())
+}
+
+func TestFragments_EachConfigPath(t *testing.T) {
+	first, second := t.TempDir(), t.TempDir()
+	require.NoError(t, os.Mkdir(filepath.Join(first, FragmentDir), 0o755))
+	require.NoError(t, os.Mkdir(filepath.Join(second, FragmentDir), 0o755))
+	writeFile(t, first, "conf.d/db.yaml", "app:\n  host: first\n  port: 1\n")
+	writeFile(t, second, "conf.d/db.yaml", "app:\n  host: second\n")
+
+	loader, err := NewE(WithConfigPaths(first, second))
+	require.NoError(t, err)
+
+	var cfg profileConfig
+	require.NoError(t, loader.Bind(&cfg))
+	assert.Equal(t, "second", cfg.Host)
+	assert.Equal(t, 1, cfg.Port)
+}
+
+func TestFragments_Symlinks(t *testing.T) {
+	dir := t.TempDir()
+	data := filepath.Join(dir, FragmentDir, "..data")
+	require.NoError(t, os.MkdirAll(data, 0o755))
+	writeFile(t, dir, "conf.d/..data/kafka.yaml", "app:\n  host: linked\n")
+	require.NoError(t, os.Symlink(filepath.Join("..data", "kafka.yaml"), filepath.Join(dir, FragmentDir, "kafka.yaml")))
+
+	loader, err := NewE(WithConfigPaths(dir))
+	require.NoError(t, err)
+
+	var cfg profileConfig
+	require.NoError(t, loader.Bind(&cfg))
+	assert.Equal(t, "linked", cfg.Host)
+	assert.Len(t, loader.LoadedFiles(), 1)
+}
+
+func TestFragments_ParseError(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.Mkdir(filepath.Join(dir, FragmentDir), 0o755))
+	writeFile(t, dir, "conf.d/broken.yaml", malformedYAML)
+
+	_, err := NewE(WithConfigPaths(dir))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), filepath.Join(dir, "conf.d/broken.yaml"))
+}
@@ -3,9 +3,11 @@ package configx
 import (
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/spf13/viper"
+	"github.com/stretchr/testify/require"
 )
 
 type DBConfig struct {
@@ -16,6 +18,15 @@ type DBConfig struct {
 
 func (DBConfig) Prefix() string { return "db" }
 
+// newTestLoader returns a loader reading yaml, failing the test when it
+// cannot be parsed.
+func newTestLoader(t *testing.T, yaml string, opts ...Option) Loader {
+	t.Helper()
+	loader, err := NewWithReader(strings.NewReader(yaml), opts...)
+	require.NoError(t, err)
+	return loader
+}
+
 func TestBindEnv_BindsAliasesAndReadsEnv(t *testing.T) {
 	// Create a temporary config directory with a base.yaml file.
 	dir := t.TempDir()
@@ -0,0 +1,192 @@
+package configx
+
+import (
+	"encoding"
+	"encoding/base64"
+	"fmt"
+	"math"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/mitchellh/mapstructure"
+)
+
+// ByteSize is a number of bytes that decodes from human-readable sizes such
+// as "512", "64MiB", "1.5GB" or "10Ki". Decimal units (K, KB, M, MB, ...) are
+// powers of 1000 and binary units (Ki, KiB, Mi, MiB, ...) powers of 1024.
+//
+// Example:
+//
+//	type CacheConfig struct {
+//	    MaxSize configx.ByteSize `mapstructure:"max_size"`
+//	}
+type ByteSize int64
+
+var byteSizeUnits = map[string]float64{
+	"":  1,
+	"b": 1,
+	"k": 1e3, "kb": 1e3, "ki": 1 << 10, "kib": 1 << 10,
+	"m": 1e6, "mb": 1e6, "mi": 1 << 20, "mib": 1 << 20,
+	"g": 1e9, "gb": 1e9, "gi": 1 << 30, "gib": 1 << 30,
+	"t": 1e12, "tb": 1e12, "ti": 1 << 40, "tib": 1 << 40,
+	"p": 1e15, "pb": 1e15, "pi": 1 << 50, "pib": 1 << 50,
+}
+
+// ParseByteSize parses a human-readable byte size.
+func ParseByteSize(s string) (ByteSize, error) {
+	s = strings.TrimSpace(s)
+	i := strings.IndexFunc(s, func(r rune) bool {
+		return !unicode.IsDigit(r) && r != '.'
+	})
+	if i < 0 {
+		i = len(s)
+	}
+
+	num, err := strconv.ParseFloat(s[:i], 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid byte size %q", s)
+	}
+	unit, ok := byteSizeUnits[strings.ToLower(strings.TrimSpace(s[i:]))]
+	if !ok {
+		return 0, fmt.Errorf("invalid byte size unit in %q", s)
+	}
+
+	size := num * unit
+	// MaxInt64 rounds up to 2^63 as a float64, which no longer fits
+	if size >= math.MaxInt64 {
+		return 0, fmt.Errorf("byte size %q overflows int64", s)
+	}
+	return ByteSize(size), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (b *ByteSize) UnmarshalText(text []byte) error {
+	size, err := ParseByteSize(string(text))
+	if err != nil {
+		return err
+	}
+	*b = size
+	return nil
+}
+
+// String formats the size with the largest exact binary unit.
+func (b ByteSize) String() string {
+	units := []string{"B", "KiB", "MiB", "GiB", "TiB", "PiB"}
+	n, i := int64(b), 0
+	for n != 0 && n%1024 == 0 && i < len(units)-1 {
+		n /= 1024
+		i++
+	}
+	return strconv.FormatInt(n, 10) + units[i]
+}
+
+// Base64Bytes is a []byte that decodes from base64 strings (standard or URL
+// alphabet, with or without padding). Plain []byte fields hold the raw
+// string instead.
+//
+// Example:
+//
+//	type SigningConfig struct {
+//	    Key configx.Base64Bytes `mapstructure:"key"` // key: aGVsbG8=
+//	}
+type Base64Bytes []byte
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (b *Base64Bytes) UnmarshalText(text []byte) error {
+	s := strings.TrimSpace(string(text))
+	for _, enc := range []*base64.Encoding{base64.StdEncoding, base64.RawStdEncoding, base64.URLEncoding, base64.RawURLEncoding} {
+		if decoded, err := enc.DecodeString(s); err == nil {
+			*b = decoded
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid base64 value")
+}
+
+// defaultDecodeHook returns the built-in decode hook chain. Hooks are applied
+// in order, each seeing the output of the previous one.
+func defaultDecodeHook() mapstructure.DecodeHookFunc {
+	return mapstructure.ComposeDecodeHookFunc(
+		// Must run before the slice hook, which would split []byte on commas
+		stringToBytesHook,
+		stringToURLHook,
+		stringToLocationHook,
+		mapstructure.StringToTimeDurationHookFunc(),
+		strToRFC3339TimeHook,
+		// Covers ByteSize, Base64Bytes, net.IP, netip.Addr/Prefix,
+		// *regexp.Regexp, zapcore.Level and any other
+		// encoding.TextUnmarshaler. Must run
+		// before the slice hook since net.IP is a []byte.
+		textUnmarshalerHook,
+		mapstructure.StringToSliceHookFunc(","),
+	)
+}
+
+var (
+	bytesType           = reflect.TypeOf([]byte(nil))
+	urlType             = reflect.TypeOf(url.URL{})
+	locationType        = reflect.TypeOf(time.Location{})
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+// stringToBytesHook stores strings in []byte fields as they are.
+func stringToBytesHook(from, to reflect.Type, data any) (any, error) {
+	if from.Kind() != reflect.String || to != bytesType {
+		return data, nil
+	}
+	return []byte(data.(string)), nil
+}
+
+// stringToURLHook parses strings into url.URL and *url.URL.
+func stringToURLHook(from, to reflect.Type, data any) (any, error) {
+	if from.Kind() != reflect.String || (to != urlType && to != reflect.PointerTo(urlType)) {
+		return data, nil
+	}
+	u, err := url.Parse(data.(string))
+	if err != nil {
+		return nil, err
+	}
+	if to == urlType {
+		return *u, nil
+	}
+	return u, nil
+}
+
+// stringToLocationHook loads time zones such as "Europe/Berlin" or "UTC"
+// into *time.Location.
+func stringToLocationHook(from, to reflect.Type, data any) (any, error) {
+	if from.Kind() != reflect.String || to != reflect.PointerTo(locationType) {
+		return data, nil
+	}
+	return time.LoadLocation(data.(string))
+}
+
+// textUnmarshalerHook decodes strings into any type whose pointer (or which,
+// for pointer types, itself) implements encoding.TextUnmarshaler.
+func textUnmarshalerHook(from, to reflect.Type, data any) (any, error) {
+	if from.Kind() != reflect.String {
+		return data, nil
+	}
+
+	switch {
+	case to.Kind() == reflect.Pointer && to.Implements(textUnmarshalerType):
+		v := reflect.New(to.Elem())
+		if err := v.Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(data.(string))); err != nil {
+			return nil, err
+		}
+		return v.Interface(), nil
+
+	case reflect.PointerTo(to).Implements(textUnmarshalerType):
+		v := reflect.New(to)
+		if err := v.Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(data.(string))); err != nil {
+			return nil, err
+		}
+		return v.Elem().Interface(), nil
+	}
+
+	return data, nil
+}
@@ -0,0 +1,195 @@
+package configx
+
+import (
+	"net"
+	"net/netip"
+	"net/url"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mitchellh/mapstructure"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zapcore"
+)
+
+type richHookConfig struct {
+	CacheSize ByteSize            `mapstructure:"cache_size"`
+	RawSize   ByteSize            `mapstructure:"raw_size"`
+	Endpoint  *url.URL            `mapstructure:"endpoint"`
+	Homepage  url.URL             `mapstructure:"homepage"`
+	BindIP    net.IP              `mapstructure:"bind_ip"`
+	Allowed   []netip.Prefix      `mapstructure:"allowed"`
+	Addr      netip.Addr          `mapstructure:"addr"`
+	Pattern   *regexp.Regexp      `mapstructure:"pattern"`
+	Level     zapcore.Level       `mapstructure:"level"`
+	Zone      *time.Location      `mapstructure:"zone"`
+	Key       Base64Bytes         `mapstructure:"key"`
+	Raw       []byte              `mapstructure:"raw"`
+	Timeout   time.Duration       `mapstructure:"timeout"`
+	Tags      []string            `mapstructure:"tags"`
+	Limits    map[string]ByteSize `mapstructure:"limits"`
+}
+
+func (richHookConfig) Prefix() string { return "rich" }
+
+const richHookYAML = `
+rich:
+  cache_size: 64MiB
+  raw_size: 1024
+  endpoint: https://api.example.com:8443/v1?x=1
+  homepage: https://example.com
+  bind_ip: 10.0.0.1
+  allowed: 10.0.0.0/8,192.168.0.0/16
+  addr: "::1"
+  pattern: ^user-[0-9]+$
+  level: warn
+  zone: Europe/Berlin
+  key: aGVsbG8sIHdvcmxk
+  raw: abcd
+  timeout: 5s
+  tags: a,b
+  limits:
+    upload: 10MB
+`
+
+func TestBind_RichDecodeHooks(t *testing.T) {
+	loader, err := NewWithReader(strings.NewReader(richHookYAML))
+	require.NoError(t, err)
+
+	var cfg richHookConfig
+	require.NoError(t, loader.Bind(&cfg))
+
+	assert.Equal(t, ByteSize(64<<20), cfg.CacheSize)
+	assert.Equal(t, ByteSize(1024), cfg.RawSize)
+	require.NotNil(t, cfg.Endpoint)
+	assert.Equal(t, "api.example.com:8443", cfg.Endpoint.Host)
+	assert.Equal(t, "/v1", cfg.Endpoint.Path)
+	assert.Equal(t, "example.com", cfg.Homepage.Host)
+	assert.True(t, net.ParseIP("10.0.0.1").Equal(cfg.BindIP))
+	assert.Equal(t, []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8"), netip.MustParsePrefix("192.168.0.0/16")}, cfg.Allowed)
+	assert.Equal(t, netip.MustParseAddr("::1"), cfg.Addr)
+	require.NotNil(t, cfg.Pattern)
+	assert.True(t, cfg.Pattern.MatchString("user-42"))
+	assert.Equal(t, zapcore.WarnLevel, cfg.Level)
+	require.NotNil(t, cfg.Zone)
+	assert.Equal(t, "Europe/Berlin", cfg.Zone.String())
+	assert.Equal(t, Base64Bytes("hello, world"), cfg.Key)
+	// Plain []byte keeps the string, even when it happens to be valid base64
+	assert.Equal(t, []byte("abcd"), cfg.Raw)
+	assert.Equal(t, 5*time.Second, cfg.Timeout)
+	assert.Equal(t, []string{"a", "b"}, cfg.Tags)
+	assert.Equal(t, ByteSize(10_000_000), cfg.Limits["upload"])
+}
+
+func TestBind_RichDecodeHookErrors(t *testing.T) {
+	tests := map[string]string{
+		"byte size": "rich:\n  cache_size: 12XB\n",
+		"ip":        "rich:\n  addr: not-an-ip\n",
+		"regexp":    "rich:\n  pattern: \"[\"\n",
+		"level":     "rich:\n  level: loud\n",
+		"zone":      "rich:\n  zone: Mars/Olympus\n",
+		"base64":    "rich:\n  key: \"not base64!\"\n",
+		"url":       "rich:\n  endpoint: \"http://[::1\"\n",
+	}
+	for name, yaml := range tests {
+		t.Run(name, func(t *testing.T) {
+			loader, err := NewWithReader(strings.NewReader(yaml))
+			require.NoError(t, err)
+
+			var cfg richHookConfig
+			assert.Error(t, loader.Bind(&cfg))
+		})
+	}
+}
+
+func TestParseByteSize(t *testing.T) {
+	tests := []struct {
+		in   string
+		want ByteSize
+	}{
+		{"0", 0},
+		{"512", 512},
+		{"512B", 512},
+		{"1k", 1000},
+		{"1KB", 1000},
+		{"1Ki", 1024},
+		{"1KiB", 1024},
+		{"1.5 GiB", 3 << 29},
+		{"2TB", 2e12},
+		{"1PiB", 1 << 50},
+	}
+	for _, tt := range tests {
+		got, err := ParseByteSize(tt.in)
+		require.NoError(t, err, tt.in)
+		assert.Equal(t, tt.want, got, tt.in)
+	}
+
+	for _, bad := range []string{"", "MiB", "-1", "1XB", "1..2"} {
+		_, err := ParseByteSize(bad)
+		assert.Error(t, err, bad)
+	}
+
+	for _, huge := range []string{"100000PiB", "8192PiB", "9223372036854775808"} {
+		_, err := ParseByteSize(huge)
+		assert.ErrorContains(t, err, "overflows int64", huge)
+	}
+}
+
+func TestByteSize_String(t *testing.T) {
+	assert.Equal(t, "0B", ByteSize(0).String())
+	assert.Equal(t, "1000B", ByteSize(1000).String())
+	assert.Equal(t, "64MiB", ByteSize(64<<20).String())
+	assert.Equal(t, "1536KiB", ByteSize(1536<<10).String())
+}
+
+func TestBase64Bytes_UnmarshalText(t *testing.T) {
+	for _, in := range []string{"aGk=", "aGk", "_-8=", "/+8"} {
+		var b Base64Bytes
+		require.NoError(t, b.UnmarshalText([]byte(in)), in)
+		assert.NotEmpty(t, b, in)
+	}
+	var b Base64Bytes
+	assert.Error(t, b.UnmarshalText([]byte("not base64!")))
+}
+
+func TestWithExtraDecodeHook_AddsToDefaults(t *testing.T) {
+	type shout string
+	type shoutConfig struct {
+		Word    shout         `mapstructure:"word"`
+		Timeout time.Duration `mapstructure:"timeout"`
+	}
+
+	upper := func(from, to reflect.Type, data any) (any, error) {
+		if from.Kind() == reflect.String && to == reflect.TypeOf(shout("")) {
+			return strings.ToUpper(data.(string)), nil
+		}
+		return data, nil
+	}
+
+	loader, err := NewWithReader(strings.NewReader("shout:\n  word: hi\n  timeout: 2s\n"), WithExtraDecodeHook(upper))
+	require.NoError(t, err)
+
+	cfg, err := Value[shoutConfig](loader, "shout")
+	require.NoError(t, err)
+	assert.Equal(t, shout("HI"), cfg.Word)
+	// Default hooks still apply
+	assert.Equal(t, 2*time.Second, cfg.Timeout)
+}
+
+func TestWithDecodeHook_ReplacesDefaults(t *testing.T) {
+	type sizeConfig struct {
+		Size ByteSize `mapstructure:"size"`
+	}
+
+	loader, err := NewWithReader(strings.NewReader("size:\n  size: 64MiB\n"),
+		WithDecodeHook(mapstructure.StringToTimeDurationHookFunc()))
+	require.NoError(t, err)
+
+	// Without the default chain, ByteSize no longer parses units
+	_, err = Value[sizeConfig](loader, "size")
+	assert.Error(t, err)
+}
@@ -4,29 +4,63 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"reflect"
+	"slices"
 	"strings"
 
-	"github.com/creasty/defaults"
 	"github.com/go-playground/validator/v10"
 	"github.com/mitchellh/mapstructure"
 	"github.com/spf13/viper"
 )
 
 // viperLoader implements the Loader interface using Viper.
+//
+// Loaders returned by Sub share the warning log and registries of their root
+// loader, while each loader created by New has its own, so applications and
+// tests sharing a process do not see each other's configs and prefixes.
 type viperLoader struct {
 	v          *viper.Viper
 	decodeHook mapstructure.DecodeHookFunc
-	boundKeys  map[string]bool
-	envPrefix  string
+	// boundKeys maps keys bound with BindEnv to their env var names.
+	boundKeys map[string][]string
+	// dotenv holds the values read from dotenv files.
+	dotenv    map[string]string
+	envPrefix string
+	// envReplacer maps config keys to environment variable names.
+	envReplacer *strings.Replacer
+	// loadErr records a failure while reading config files. New cannot return
+	// it directly, so it is reported by Bind instead.
+	loadErr error
+	// loadedFiles lists the merged config files in load order.
+	loadedFiles []string
+	// scope is the key prefix of a loader returned by Sub, empty at the root.
+	scope              string
+	strictDeprecations bool
+	// warnings logs loader warnings, such as deprecated keys, once each.
+	warnings *warningLog
+	// configs records the configs bound through Provide.
+	configs *configRegistry
+	// prefixes records the owners of bound prefixes.
+	prefixes *prefixRegistry
 }
 
 // New creates a new Loader with optional configuration.
 //
 // Configuration Precedence (highest to lowest):
 //  1. Environment variables (customizable prefix, see below)
-//  2. Environment-specific config file ({APP_ENV}.yaml)
-//  3. Base config file (base.yaml)
-//  4. Struct tag defaults (default:"value")
+//  2. Environment-specific config files ({APP_ENV}.yaml)
+//  3. Config fragments (conf.d/*.yaml, in lexical order)
+//  4. Base config file (base.yaml)
+//  5. Struct tag defaults (default:"value")
+//
+// APP_PROFILES (or APP_ENV when it is unset) may list several overlays,
+// separated by commas, which are merged in order after base:
+//
+//	APP_PROFILES=prod,prod-eu,prod-eu-canary
+//	// base -> prod -> prod-eu -> prod-eu-canary
+//
+// Any config file can compose other files with a top-level include key; see
+// IncludeKey. Include cycles are reported as load errors.
 //
 // Environment Prefix Precedence (highest to lowest):
 //  1. WithEnvPrefix() option (in code)
@@ -37,6 +71,13 @@ type viperLoader struct {
 //   - CONFIG_PATHS environment variable (comma-separated)
 //   - WithConfigPaths() option
 //
+// Explicit files added with WithFiles() or WithOptionalFiles() are layered on
+// top of the environment-specific config file, in the order given.
+//
+// Config files that are missing are skipped, but a file that exists and cannot
+// be parsed (or a missing required file) is a load error. New cannot return it,
+// so every Bind call reports it instead; use NewE to fail immediately.
+//
 // Example:
 //
 //	// Option 1: Use ENV_PREFIX environment variable
@@ -51,16 +92,31 @@ type viperLoader struct {
 //	)
 //	// Uses MYAPP_* environment variables
 func New(opts ...Option) Loader {
-	cfg := &LoaderConfig{
-		ConfigPaths: []string{DefaultConfigPath},
-		EnvPrefix:   DefaultEnvPrefix,
-		EnvReplacer: strings.NewReplacer(".", "_", "-", "_"),
-		DecodeHooks: mapstructure.ComposeDecodeHookFunc(
-			mapstructure.StringToTimeDurationHookFunc(),
-			mapstructure.StringToSliceHookFunc(","),
-			strToRFC3339TimeHook,
-		),
+	l, err := load(opts)
+	if err != nil {
+		l.loadErr = err
+	}
+	return l
+}
+
+// NewE is like New but returns config file errors instead of deferring them
+// to Bind. Prefer it as the Fx constructor so that a malformed file fails
+// application startup with a message naming the file and line:
+//
+//	fx.Provide(configx.NewE)
+//	// failed to parse config file configs/prod.yaml: While parsing config: yaml: line 3: ...
+func NewE(opts ...Option) (Loader, error) {
+	l, err := load(opts)
+	if err != nil {
+		return nil, err
 	}
+	return l, nil
+}
+
+// load builds the viper loader for New and NewE. The loader is always
+// returned so New can hand it out with the error attached.
+func load(opts []Option) (*viperLoader, error) {
+	cfg := defaultLoaderConfig()
 
 	// Check CONFIG_PATHS env var for backward compatibility
 	if paths := strings.TrimSpace(os.Getenv(EnvConfigPaths)); paths != "" {
@@ -80,53 +136,60 @@ func New(opts ...Option) Loader {
 		opt(cfg)
 	}
 
-	v := viper.New()
+	codecs := newCodecRegistry(cfg)
+	v := viper.NewWithOptions(viper.WithCodecRegistry(codecs))
+	m := &fileMerger{v: v, codecs: codecs, keys: newKeySource(cfg)}
 
-	// Add config paths
-	for _, path := range cfg.ConfigPaths {
-		if p := strings.TrimSpace(path); p != "" {
-			v.AddConfigPath(p)
+	loadErr := func() error {
+		// Layering: base + conf.d fragments + environment-specific overlays
+		if err := m.mergeSearchPath(cfg.ConfigPaths, BaseConfigFile); err != nil {
+			return err
 		}
-	}
-
-	// Layering: base + environment-specific config
-	v.SetConfigName(BaseConfigFile)
-	_ = v.MergeInConfig()
 
-	if env := strings.TrimSpace(os.Getenv(EnvAppEnv)); env != "" {
-		v.SetConfigName(env)
-		_ = v.MergeInConfig()
-	}
+		if err := m.mergeFragments(cfg.ConfigPaths); err != nil {
+			return err
+		}
 
-	if envPrefix := v.GetString("core.config.env_prefix"); envPrefix != "" {
-		cfg.EnvPrefix = envPrefix
-	}
+		for _, profile := range profiles() {
+			if err := m.mergeSearchPath(cfg.ConfigPaths, profile); err != nil {
+				return err
+			}
+		}
 
-	// Check ENV_PREFIX env var for global prefix override
-	if envPrefix := strings.TrimSpace(os.Getenv(EnvPrefix)); envPrefix != "" {
-		cfg.EnvPrefix = envPrefix
-	}
+		// Explicit files override the search-path files, in order
+		for _, file := range cfg.Files {
+			if err := m.merge(file); err != nil {
+				return err
+			}
+		}
+		return nil
+	}()
 
-	// Environment variable override
-	v.SetEnvPrefix(cfg.EnvPrefix)
-	v.SetEnvKeyReplacer(cfg.EnvReplacer)
-	v.AutomaticEnv()
+	l := newViperLoader(v, cfg, m.env)
+	l.loadedFiles = m.loaded
+	return l, loadErr
+}
 
-	// Apply WithEnvPrefix override (highest priority)
-	if cfg.OverrideEnvPrefix != "" {
-		cfg.EnvPrefix = cfg.OverrideEnvPrefix
-		v.SetEnvPrefix(cfg.EnvPrefix)
+// profiles returns the config overlays named by APP_PROFILES, falling back to
+// APP_ENV, in the order they should be merged.
+func profiles() []string {
+	raw := strings.TrimSpace(os.Getenv(EnvAppProfiles))
+	if raw == "" {
+		raw = strings.TrimSpace(os.Getenv(EnvAppEnv))
 	}
 
-	return &viperLoader{
-		v:          v,
-		decodeHook: cfg.DecodeHooks,
-		boundKeys:  make(map[string]bool),
-		envPrefix:  cfg.EnvPrefix,
+	var out []string
+	for p := range strings.SplitSeq(raw, ",") {
+		if p = strings.TrimSpace(p); p != "" {
+			out = append(out, p)
+		}
 	}
+	return out
 }
 
-// NewWithReader creates a new Loader from an in-memory YAML source.
+// NewWithReader creates a new Loader from an in-memory source.
+// The source is parsed as YAML unless another format is selected with
+// WithFormat().
 // This is designed for testing and allows modules to inject configuration
 // without writing temporary files or duplicating YAML parsing logic.
 //
@@ -158,41 +221,73 @@ func New(opts ...Option) Loader {
 //	assert.Equal(t, 30*time.Second, cfg.Timeout)
 func NewWithReader(r io.Reader, opts ...Option) (Loader, error) {
 	// Apply same default configuration as New()
-	cfg := &LoaderConfig{
-		ConfigPaths: []string{DefaultConfigPath},
-		EnvPrefix:   DefaultEnvPrefix,
-		EnvReplacer: strings.NewReplacer(".", "_", "-", "_"),
-		DecodeHooks: mapstructure.ComposeDecodeHookFunc(
-			mapstructure.StringToTimeDurationHookFunc(),
-			mapstructure.StringToSliceHookFunc(","),
-			strToRFC3339TimeHook,
-		),
-	}
+	cfg := defaultLoaderConfig()
 
 	// Apply user options
 	for _, opt := range opts {
 		opt(cfg)
 	}
 
-	v := viper.New()
-	v.SetConfigType("yaml")
+	v := viper.NewWithOptions(viper.WithCodecRegistry(newCodecRegistry(cfg)))
+	v.SetConfigType(cfg.Format)
 
 	// Read configuration from in-memory source
 	if err := v.ReadConfig(r); err != nil {
 		return nil, fmt.Errorf("failed to read config from reader: %w", err)
 	}
 
-	// Check for env_prefix in YAML config (for consistency with New())
+	// Replace ENC[...] values with their plaintext
+	settings := v.AllSettings()
+	if err := newKeySource(cfg).decryptSettings(settings); err != nil {
+		return nil, fmt.Errorf("failed to decrypt config from reader: %w", err)
+	}
+	if err := v.MergeConfigMap(settings); err != nil {
+		return nil, fmt.Errorf("failed to read config from reader: %w", err)
+	}
+
+	return newViperLoader(v, cfg, nil), nil
+}
+
+// defaultLoaderConfig returns the LoaderConfig shared by New and NewWithReader
+// before options are applied.
+func defaultLoaderConfig() *LoaderConfig {
+	return &LoaderConfig{
+		ConfigPaths: []string{DefaultConfigPath},
+		EnvPrefix:   DefaultEnvPrefix,
+		EnvReplacer: strings.NewReplacer(".", "_", "-", "_"),
+		DecodeHooks: defaultDecodeHook(),
+		Format:      FormatYAML,
+	}
+}
+
+// newCodecRegistry returns a codec registry with the built-in formats plus
+// any codecs registered through WithCodec.
+func newCodecRegistry(cfg *LoaderConfig) *viper.DefaultCodecRegistry {
+	codecs := viper.NewCodecRegistry()
+	for format, codec := range cfg.Codecs {
+		_ = codecs.RegisterCodec(format, codec)
+	}
+	return codecs
+}
+
+// newViperLoader resolves the environment prefix and enables env overrides
+// on a viper instance that already holds the file settings.
+func newViperLoader(v *viper.Viper, cfg *LoaderConfig, dotenv map[string]string) *viperLoader {
+	// Check for env_prefix in config (core.config.env_prefix)
 	if envPrefix := v.GetString("core.config.env_prefix"); envPrefix != "" {
 		cfg.EnvPrefix = envPrefix
 	}
 
-	// Check ENV_PREFIX env var for global prefix override
-	if envPrefix := strings.TrimSpace(os.Getenv(EnvPrefix)); envPrefix != "" {
+	// Check ENV_PREFIX env var (or dotenv entry) for global prefix override
+	envPrefix, ok := os.LookupEnv(EnvPrefix)
+	if !ok {
+		envPrefix = dotenv[EnvPrefix]
+	}
+	if envPrefix = strings.TrimSpace(envPrefix); envPrefix != "" {
 		cfg.EnvPrefix = envPrefix
 	}
 
-	// Environment variable override support
+	// Environment variable override
 	v.SetEnvPrefix(cfg.EnvPrefix)
 	v.SetEnvKeyReplacer(cfg.EnvReplacer)
 	v.AutomaticEnv()
@@ -204,53 +299,67 @@ func NewWithReader(r io.Reader, opts ...Option) (Loader, error) {
 	}
 
 	return &viperLoader{
-		v:          v,
-		decodeHook: cfg.DecodeHooks,
-		boundKeys:  make(map[string]bool),
-		envPrefix:  cfg.EnvPrefix,
-	}, nil
+		v:           v,
+		decodeHook:  cfg.decodeHook(),
+		boundKeys:   make(map[string][]string),
+		envPrefix:   cfg.EnvPrefix,
+		envReplacer: cfg.EnvReplacer,
+		dotenv:      dotenv,
+
+		strictDeprecations: cfg.StrictDeprecations,
+		warnings:           newWarningLog(cfg.Logger),
+		configs:            newConfigRegistry(),
+		prefixes:           newPrefixRegistry(),
+	}
 }
 
 // Bind loads configuration into the provided struct.
-// Configuration precedence: ENV > YAML > Defaults
+// Configuration precedence: ENV > files > Defaults. See Loader for details.
 func (l *viperLoader) Bind(props Configurable) error {
+	if l.loadErr != nil {
+		return l.loadErr
+	}
+
 	if props == nil {
 		return fmt.Errorf("props is nil")
 	}
 
-	prefix := normalizeKey(props.Prefix())
-	if prefix == "" {
+	if normalizeKey(props.Prefix()) == "" {
 		return fmt.Errorf("props.Prefix() cannot be empty")
 	}
+	prefix := l.key(props.Prefix())
+	l.prefixes.claim(prefix, reflect.TypeOf(props))
 
-	rebuildSettings := make(map[string]any)
+	rebuildSettings := l.settings(prefix)
 
-	// Iterate all keys from Viper (includes YAML + env vars)
-	for _, fullKey := range l.v.AllKeys() {
-		if strings.HasPrefix(fullKey, prefix+".") {
-			value := l.v.Get(fullKey)
-			if value != nil {
-				keyWithoutPrefix := strings.TrimPrefix(fullKey, prefix+".")
-				setNestedValue(rebuildSettings, strings.Split(keyWithoutPrefix, "."), value)
-			}
-		}
+	// Env vars for map entries and list elements that no config file declares
+	if err := l.discoverEnv(rebuildSettings, prefix, reflect.TypeOf(props)); err != nil {
+		return err
 	}
 
-	// Check explicitly bound keys (for env-only sensitive values)
-	for boundKey := range l.boundKeys {
-		keyWithoutPrefix := strings.TrimPrefix(boundKey, prefix+".")
-		if keyWithoutPrefix != boundKey && keyWithoutPrefix != "" {
-			value := l.v.Get(boundKey)
-			if value != nil {
-				setNestedValue(rebuildSettings, strings.Split(keyWithoutPrefix, "."), value)
-			}
-		}
+	// Fields tagged env:"NAME" read from the named env vars
+	if err := l.applyEnvTags(rebuildSettings, prefix, reflect.TypeOf(props)); err != nil {
+		return err
+	}
+
+	// Values still set under deprecated keys fill in their replacements
+	if err := l.applyKeyAliases(rebuildSettings, prefix, props); err != nil {
+		return err
+	}
+
+	// Apply struct tag defaults first, so that decoded values (including
+	// explicit zero values such as false or 0) take precedence over them
+	if err := applyDefaults(reflect.ValueOf(props), l.decodeHook); err != nil {
+		return fmt.Errorf("failed to set defaults: %w", err)
 	}
 
 	// Decode into struct
 	decoder, err := mapstructure.NewDecoder(&mapstructure.DecoderConfig{
-		Result:           props,
-		DecodeHook:       l.decodeHook,
+		Result: props,
+		DecodeHook: mapstructure.ComposeDecodeHookFunc(
+			defaultsDecodeHook(l.decodeHook),
+			l.decodeHook,
+		),
 		WeaklyTypedInput: true,
 	})
 	if err != nil {
@@ -261,10 +370,8 @@ func (l *viperLoader) Bind(props Configurable) error {
 		return fmt.Errorf("failed to decode config for prefix '%s': %w", prefix, err)
 	}
 
-	// Apply struct tag defaults
-	if err := defaults.Set(props); err != nil {
-		return fmt.Errorf("failed to set defaults: %w", err)
-	}
+	// Computed defaults that depend on decoded values
+	callDefaultsSetters(reflect.ValueOf(props))
 
 	// Validate configuration
 	if err := validator.New().Struct(props); err != nil {
@@ -274,6 +381,42 @@ func (l *viperLoader) Bind(props Configurable) error {
 	return nil
 }
 
+// settings rebuilds the nested settings map below prefix from every key viper
+// knows (files + env vars) and from keys bound with BindEnv, so that env
+// overrides apply to individual nested keys.
+func (l *viperLoader) settings(prefix string) map[string]any {
+	out := make(map[string]any)
+
+	// Iterate all keys from Viper (includes YAML + env vars)
+	for _, fullKey := range l.v.AllKeys() {
+		if strings.HasPrefix(fullKey, prefix+".") {
+			value := l.get(fullKey)
+			if value != nil {
+				keyWithoutPrefix := strings.TrimPrefix(fullKey, prefix+".")
+				setNestedValue(out, strings.Split(keyWithoutPrefix, "."), value)
+			}
+		}
+	}
+
+	// Check explicitly bound keys (for env-only sensitive values)
+	for boundKey := range l.boundKeys {
+		keyWithoutPrefix := strings.TrimPrefix(boundKey, prefix+".")
+		if keyWithoutPrefix != boundKey && keyWithoutPrefix != "" {
+			value := l.get(boundKey)
+			if value != nil {
+				setNestedValue(out, strings.Split(keyWithoutPrefix, "."), value)
+			}
+		}
+	}
+
+	return out
+}
+
+// LoadedFiles returns the config files that were merged, in load order.
+func (l *viperLoader) LoadedFiles() []string {
+	return slices.Clone(l.loadedFiles)
+}
+
 // BindEnv explicitly binds configuration keys to environment variables.
 // Use this for sensitive values that should only come from environment.
 //
@@ -282,10 +425,10 @@ func (l *viperLoader) Bind(props Configurable) error {
 //	loader.BindEnv("db.dsn", "DATABASE_URL")
 //	loader.BindEnv("api.key")
 func (l *viperLoader) BindEnv(key string, envVars ...string) error {
-	normalizedKey := normalizeKey(key)
-	if normalizedKey == "" {
+	if normalizeKey(key) == "" {
 		return fmt.Errorf("cannot bind empty key")
 	}
+	normalizedKey := l.key(key)
 
 	// Build env var names using a replacer that mirrors how we convert viper keys
 	// into env var names (dot and dash become underscores, then upper-cased).
@@ -336,7 +479,7 @@ func (l *viperLoader) BindEnv(key string, envVars ...string) error {
 
 	// Track bound keys for resolution during Bind()
 	if l.boundKeys != nil {
-		l.boundKeys[normalizedKey] = true
+		l.boundKeys[normalizedKey] = args[1:]
 	}
 
 	return nil
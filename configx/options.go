@@ -1,21 +1,36 @@
 package configx
 
 import (
+	"log/slog"
 	"strings"
 
 	"github.com/mitchellh/mapstructure"
+	"github.com/spf13/viper"
 )
 
 // Option configures the Loader during initialization.
 type Option func(*LoaderConfig)
 
 // LoaderConfig holds configuration for creating a Loader.
+// DecodeHooks holds the default decode hook chain; ExtraDecodeHooks run
+// before it.
 type LoaderConfig struct {
 	ConfigPaths       []string
 	EnvPrefix         string
 	OverrideEnvPrefix string
 	EnvReplacer       *strings.Replacer
 	DecodeHooks       mapstructure.DecodeHookFunc
+	ExtraDecodeHooks  []mapstructure.DecodeHookFunc
+	Files             []ConfigFile
+	Format            string
+	Codecs            map[string]viper.Codec
+	// Logger receives loader warnings; nil keeps them until SetLogger.
+	Logger             *slog.Logger
+	StrictDeprecations bool
+	// EncryptionKey or EncryptionKeyFile decrypt ENC[...] values; when both
+	// are unset the key comes from the environment.
+	EncryptionKey     []byte
+	EncryptionKeyFile string
 }
 
 // WithConfigPaths sets the configuration paths for the Loader.
@@ -68,8 +83,14 @@ func WithEnvKeyReplacer(replacer *strings.Replacer) Option {
 	}
 }
 
-// WithDecodeHook sets custom decode hooks for type conversions during unmarshaling.
-// Default: Supports time.Duration, []string (comma-separated), and time.Time (RFC3339)
+// WithDecodeHook sets custom decode hooks for type conversions during
+// unmarshaling, replacing the default chain. Use WithExtraDecodeHook to keep
+// the defaults.
+//
+// Default hooks support time.Duration, []string (comma-separated), time.Time
+// (RFC3339), ByteSize ("64MiB"), url.URL, *time.Location, raw []byte,
+// Base64Bytes and any encoding.TextUnmarshaler (net.IP, netip.Prefix,
+// *regexp.Regexp, zapcore.Level, ...).
 //
 // Example:
 //
@@ -84,3 +105,174 @@ func WithDecodeHook(hook mapstructure.DecodeHookFunc) Option {
 		}
 	}
 }
+
+// WithExtraDecodeHook adds a custom decode hook in front of the default
+// chain, or of the chain set with WithDecodeHook, so it can take precedence
+// for a type while the other conversions keep working. Repeated calls add
+// hooks in order.
+//
+// Example:
+//
+//	loader := configx.New(
+//	    configx.WithExtraDecodeHook(myCustomHook),
+//	)
+func WithExtraDecodeHook(hook mapstructure.DecodeHookFunc) Option {
+	return func(cfg *LoaderConfig) {
+		if hook != nil {
+			cfg.ExtraDecodeHooks = append(cfg.ExtraDecodeHooks, hook)
+		}
+	}
+}
+
+// WithFiles layers explicit configuration files on top of the files found in
+// the config paths. Files are merged in the given order, so later files
+// override earlier ones. Every file is required: a missing file fails loading.
+// The format is derived from the extension (.yaml, .yml, .json, .toml,
+// .env); other formats such as .hcl need a codec, see WithCodec.
+//
+// Example:
+//
+//	loader := configx.New(
+//	    configx.WithFiles("/etc/myapp/app.toml", "/run/secrets/app.json"),
+//	)
+func WithFiles(paths ...string) Option {
+	return withConfigFiles(false, paths)
+}
+
+// WithOptionalFiles is like WithFiles but silently skips files that do not
+// exist. Files that exist but cannot be parsed still fail loading.
+//
+// Example:
+//
+//	loader := configx.New(
+//	    configx.WithFiles("./configs/app.yaml"),
+//	    configx.WithOptionalFiles("./configs/local.yaml", ".env"),
+//	)
+func WithOptionalFiles(paths ...string) Option {
+	return withConfigFiles(true, paths)
+}
+
+func withConfigFiles(optional bool, paths []string) Option {
+	return func(cfg *LoaderConfig) {
+		for _, p := range paths {
+			if p = strings.TrimSpace(p); p != "" {
+				cfg.Files = append(cfg.Files, ConfigFile{Path: p, Optional: optional})
+			}
+		}
+	}
+}
+
+// WithFormat sets the format of the source passed to NewWithReader.
+// Default: "yaml"
+//
+// Example:
+//
+//	loader, err := configx.NewWithReader(
+//	    strings.NewReader(`{"app": {"port": 8080}}`),
+//	    configx.WithFormat(configx.FormatJSON),
+//	)
+func WithFormat(format string) Option {
+	return func(cfg *LoaderConfig) {
+		if f := normalizeFormat(format); f != "" {
+			cfg.Format = f
+		}
+	}
+}
+
+// WithCodec registers a decoder for an additional configuration format.
+// YAML, JSON, TOML and dotenv are built in. HCL support is opt-in to keep
+// its dependency out of the core module:
+//
+// Example:
+//
+//	import "github.com/go-viper/encoding/hcl"
+//
+//	loader := configx.New(
+//	    configx.WithCodec(configx.FormatHCL, hcl.Codec{}),
+//	    configx.WithFiles("./configs/app.hcl"),
+//	)
+func WithCodec(format string, codec viper.Codec) Option {
+	return func(cfg *LoaderConfig) {
+		if f := normalizeFormat(format); f != "" && codec != nil {
+			if cfg.Codecs == nil {
+				cfg.Codecs = make(map[string]viper.Codec)
+			}
+			cfg.Codecs[f] = codec
+		}
+	}
+}
+
+// WithLogger sets the logger for loader warnings, such as the use of
+// deprecated keys. Without it, warnings are kept until SetLogger is called,
+// which logx.Module does with the application logger.
+//
+// Example:
+//
+//	loader := configx.New(
+//	    configx.WithLogger(slog.New(slog.NewJSONHandler(os.Stderr, nil))),
+//	)
+func WithLogger(logger *slog.Logger) Option {
+	return func(cfg *LoaderConfig) {
+		if logger != nil {
+			cfg.Logger = logger
+		}
+	}
+}
+
+// WithStrictDeprecations makes Bind fail with ErrDeprecatedKey when a
+// deprecated key declared by a KeyAliaser is set, instead of logging a
+// warning and reading it.
+//
+// Example:
+//
+//	// In CI, catch configs that still use renamed keys
+//	loader := configx.New(configx.WithStrictDeprecations())
+func WithStrictDeprecations() Option {
+	return func(cfg *LoaderConfig) {
+		cfg.StrictDeprecations = true
+	}
+}
+
+// WithEncryptionKey sets the 32-byte AES-256 key used to decrypt ENC[...]
+// values in config files. It takes precedence over WithEncryptionKeyFile and
+// the CONFIG_ENCRYPTION_KEY(_FILE) environment variables.
+//
+// Example:
+//
+//	key, _ := configx.ParseEncryptionKey(os.Getenv("MY_CONFIG_KEY"))
+//	loader := configx.New(configx.WithEncryptionKey(key))
+func WithEncryptionKey(key []byte) Option {
+	return func(cfg *LoaderConfig) {
+		if len(key) > 0 {
+			cfg.EncryptionKey = key
+		}
+	}
+}
+
+// WithEncryptionKeyFile reads the key used to decrypt ENC[...] values from
+// path, which holds the raw 32 bytes or their base64 or hex encoding.
+//
+// Example:
+//
+//	loader := configx.New(
+//	    configx.WithEncryptionKeyFile("/run/secrets/config.key"),
+//	)
+func WithEncryptionKeyFile(path string) Option {
+	return func(cfg *LoaderConfig) {
+		if path = strings.TrimSpace(path); path != "" {
+			cfg.EncryptionKeyFile = path
+		}
+	}
+}
+
+// decodeHook returns the custom hooks followed by the default chain.
+func (cfg *LoaderConfig) decodeHook() mapstructure.DecodeHookFunc {
+	if len(cfg.ExtraDecodeHooks) == 0 {
+		return cfg.DecodeHooks
+	}
+	hooks := append([]mapstructure.DecodeHookFunc{}, cfg.ExtraDecodeHooks...)
+	if cfg.DecodeHooks != nil {
+		hooks = append(hooks, cfg.DecodeHooks)
+	}
+	return mapstructure.ComposeDecodeHookFunc(hooks...)
+}
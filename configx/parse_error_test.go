@@ -0,0 +1,73 @@
+package configx
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const malformedYAML = "app:\n  port: 8080\n  host: [unclosed\n"
+
+func TestNew_ParseErrorReportedByBind(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "base.yaml", malformedYAML)
+
+	loader := New(WithConfigPaths(dir))
+
+	var cfg AppConfig
+	err := loader.Bind(&cfg)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), filepath.Join(dir, "base.yaml"))
+	assert.Contains(t, err.Error(), "line")
+}
+
+func TestNew_EnvOverlayParseError(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "base.yaml", "app:\n  port: 8000\n")
+	writeFile(t, dir, "prod.yaml", malformedYAML)
+	t.Setenv("APP_ENV", "prod")
+
+	_, err := NewE(WithConfigPaths(dir))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), filepath.Join(dir, "prod.yaml"))
+}
+
+func TestNewE(t *testing.T) {
+	t.Run("missing files are not an error", func(t *testing.T) {
+		t.Setenv("APP_ENV", "staging")
+
+		loader, err := NewE(WithConfigPaths(t.TempDir()))
+		require.NoError(t, err)
+		require.NotNil(t, loader)
+	})
+
+	t.Run("valid files load", func(t *testing.T) {
+		dir := t.TempDir()
+		writeFile(t, dir, "base.yaml", "app:\n  port: 8000\n")
+
+		loader, err := NewE(WithConfigPaths(dir))
+		require.NoError(t, err)
+
+		var cfg AppConfig
+		require.NoError(t, loader.Bind(&cfg))
+		assert.Equal(t, 8000, cfg.Port)
+	})
+
+	t.Run("parse error", func(t *testing.T) {
+		dir := t.TempDir()
+		writeFile(t, dir, "base.yaml", malformedYAML)
+
+		loader, err := NewE(WithConfigPaths(dir))
+		require.Error(t, err)
+		assert.Nil(t, loader)
+		assert.Contains(t, err.Error(), "failed to parse config file")
+	})
+
+	t.Run("missing required file", func(t *testing.T) {
+		_, err := NewE(WithFiles(filepath.Join(t.TempDir(), "app.yaml")))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "required config file")
+	})
+}
@@ -0,0 +1,117 @@
+package configx
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// PrefixOwner lists the config types that bind a prefix.
+type PrefixOwner struct {
+	// Prefix is the full config key, including the scope of a Sub loader.
+	Prefix string
+	// Types are the config struct types bound under Prefix, in the order
+	// they were first bound.
+	Types []reflect.Type
+}
+
+// PrefixConflict reports two config types that read the same keys: either
+// several types bind the same prefix, or one type's prefix contains
+// another's (such as "core" and "core.logger").
+type PrefixConflict struct {
+	// Prefix is the shorter (or shared) prefix and Types its owners.
+	Prefix string
+	Types  []reflect.Type
+	// Nested is the prefix inside Prefix and NestedTypes its owners. Both
+	// are empty when the conflict is a duplicate prefix.
+	Nested      string
+	NestedTypes []reflect.Type
+}
+
+// String describes the conflict for log messages.
+func (c PrefixConflict) String() string {
+	if c.Nested == "" {
+		return fmt.Sprintf("prefix %q is bound by %s", c.Prefix, typeNames(c.Types))
+	}
+	return fmt.Sprintf("prefix %q of %s contains %q of %s",
+		c.Prefix, typeNames(c.Types), c.Nested, typeNames(c.NestedTypes))
+}
+
+func typeNames(types []reflect.Type) string {
+	names := make([]string, len(types))
+	for i, t := range types {
+		names[i] = t.String()
+	}
+	return strings.Join(names, ", ")
+}
+
+// Prefixes returns every prefix claimed by Bind or Provide with loader or the
+// loaders returned by its Sub method, sorted, with the Go types that own it.
+func Prefixes(loader Loader) []PrefixOwner {
+	vl, ok := loader.(*viperLoader)
+	if !ok {
+		return nil
+	}
+	r := vl.prefixes
+	r.RLock()
+	defer r.RUnlock()
+
+	out := make([]PrefixOwner, 0, len(r.owners))
+	for p, types := range r.owners {
+		out = append(out, PrefixOwner{Prefix: p, Types: slices.Clone(types)})
+	}
+	slices.SortFunc(out, func(a, b PrefixOwner) int { return strings.Compare(a.Prefix, b.Prefix) })
+	return out
+}
+
+// PrefixConflicts returns the duplicate and overlapping prefixes among the
+// Prefixes of loader, sorted by prefix. A type binding several nested
+// prefixes itself is not a conflict.
+func PrefixConflicts(loader Loader) []PrefixConflict {
+	owners := Prefixes(loader)
+
+	var out []PrefixConflict
+	for i, a := range owners {
+		if len(a.Types) > 1 {
+			out = append(out, PrefixConflict{Prefix: a.Prefix, Types: a.Types})
+		}
+		// Sorted order puts every prefix nested in a right after it
+		for _, b := range owners[i+1:] {
+			if !strings.HasPrefix(b.Prefix, a.Prefix+".") {
+				if !strings.HasPrefix(b.Prefix, a.Prefix) {
+					break
+				}
+				continue
+			}
+			if !slices.Equal(a.Types, b.Types) {
+				out = append(out, PrefixConflict{Prefix: a.Prefix, Types: a.Types, Nested: b.Prefix, NestedTypes: b.Types})
+			}
+		}
+	}
+	return out
+}
+
+// prefixRegistry records the owners of the prefixes bound with one loader.
+type prefixRegistry struct {
+	sync.RWMutex
+	owners map[string][]reflect.Type
+}
+
+func newPrefixRegistry() *prefixRegistry {
+	return &prefixRegistry{owners: map[string][]reflect.Type{}}
+}
+
+// claim records typ as an owner of prefix.
+func (r *prefixRegistry) claim(prefix string, typ reflect.Type) {
+	for typ.Kind() == reflect.Pointer {
+		typ = typ.Elem()
+	}
+
+	r.Lock()
+	defer r.Unlock()
+	if !slices.Contains(r.owners[prefix], typ) {
+		r.owners[prefix] = append(r.owners[prefix], typ)
+	}
+}
@@ -0,0 +1,94 @@
+package configx
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type ownerParentConfig struct {
+	Name string `mapstructure:"name"`
+}
+
+func (ownerParentConfig) Prefix() string { return "owners" }
+
+type ownerChildConfig struct {
+	Level string `mapstructure:"level"`
+}
+
+func (ownerChildConfig) Prefix() string { return "owners.child" }
+
+type ownerDuplicateConfig struct {
+	Name string `mapstructure:"name"`
+}
+
+func (ownerDuplicateConfig) Prefix() string { return "owners" }
+
+type ownerSiblingConfig struct{}
+
+func (ownerSiblingConfig) Prefix() string { return "owners2" }
+
+func TestPrefixes_TracksOwners(t *testing.T) {
+	loader, err := NewWithReader(strings.NewReader(""))
+	require.NoError(t, err)
+
+	require.NoError(t, loader.Bind(&ownerParentConfig{}))
+	require.NoError(t, loader.Bind(&ownerParentConfig{}))
+	require.NoError(t, loader.Sub("scoped").Bind(&ownerChildConfig{}))
+	require.NoError(t, loader.Bind(&ownerSiblingConfig{}))
+
+	assert.Equal(t, []PrefixOwner{
+		{Prefix: "owners", Types: []reflect.Type{reflect.TypeFor[ownerParentConfig]()}},
+		{Prefix: "owners2", Types: []reflect.Type{reflect.TypeFor[ownerSiblingConfig]()}},
+		{Prefix: "scoped.owners.child", Types: []reflect.Type{reflect.TypeFor[ownerChildConfig]()}},
+	}, Prefixes(loader))
+
+	// Siblings, scoped binds and rebinding the same type do not conflict
+	assert.Empty(t, PrefixConflicts(loader))
+	assert.Nil(t, Prefixes(nil))
+}
+
+func TestPrefixes_PerLoader(t *testing.T) {
+	first, err := NewWithReader(strings.NewReader(""))
+	require.NoError(t, err)
+	second, err := NewWithReader(strings.NewReader(""))
+	require.NoError(t, err)
+
+	// The same types bound by separate loaders, as by two apps in one
+	// process, do not conflict
+	require.NoError(t, first.Bind(&ownerParentConfig{}))
+	require.NoError(t, second.Bind(&ownerDuplicateConfig{}))
+	require.NoError(t, second.Bind(&ownerChildConfig{}))
+
+	assert.Empty(t, PrefixConflicts(first))
+	require.Len(t, PrefixConflicts(second), 1)
+	assert.Len(t, Prefixes(first), 1)
+}
+
+func TestPrefixConflicts(t *testing.T) {
+	loader, err := NewWithReader(strings.NewReader(""))
+	require.NoError(t, err)
+	loader = loader.Sub("conflicts")
+
+	require.NoError(t, loader.Bind(&ownerParentConfig{}))
+	require.NoError(t, loader.Bind(&ownerChildConfig{}))
+	require.NoError(t, loader.Bind(&ownerDuplicateConfig{}))
+
+	conflicts := PrefixConflicts(loader)
+	require.Len(t, conflicts, 2)
+
+	parents := []reflect.Type{reflect.TypeFor[ownerParentConfig](), reflect.TypeFor[ownerDuplicateConfig]()}
+	assert.Equal(t, PrefixConflict{Prefix: "conflicts.owners", Types: parents}, conflicts[0])
+	assert.Equal(t, `prefix "conflicts.owners" is bound by configx.ownerParentConfig, configx.ownerDuplicateConfig`, conflicts[0].String())
+
+	assert.Equal(t, PrefixConflict{
+		Prefix:      "conflicts.owners",
+		Types:       parents,
+		Nested:      "conflicts.owners.child",
+		NestedTypes: []reflect.Type{reflect.TypeFor[ownerChildConfig]()},
+	}, conflicts[1])
+	assert.Equal(t, `prefix "conflicts.owners" of configx.ownerParentConfig, configx.ownerDuplicateConfig contains "conflicts.owners.child" of configx.ownerChildConfig`, conflicts[1].String())
+}
@@ -0,0 +1,152 @@
+package configx
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type profileConfig struct {
+	Region string `mapstructure:"region"`
+	Canary bool   `mapstructure:"canary"`
+	Port   int    `mapstructure:"port"`
+	Host   string `mapstructure:"host"`
+}
+
+func (profileConfig) Prefix() string { return "app" }
+
+func writeProfileFiles(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	writeFile(t, dir, "base.yaml", "app:\n  port: 8000\n  host: base\n  region: none\n")
+	writeFile(t, dir, "prod.yaml", "app:\n  port: 80\n  host: prod\n")
+	writeFile(t, dir, "prod-eu.yaml", "app:\n  region: eu\n  host: prod-eu\n")
+	writeFile(t, dir, "prod-eu-canary.yaml", "app:\n  canary: true\n")
+	return dir
+}
+
+func TestProfiles_StackedOverlays(t *testing.T) {
+	dir := writeProfileFiles(t)
+	t.Setenv("APP_ENV", "prod, prod-eu ,prod-eu-canary")
+
+	loader, err := NewE(WithConfigPaths(dir))
+	require.NoError(t, err)
+
+	var cfg profileConfig
+	require.NoError(t, loader.Bind(&cfg))
+	assert.Equal(t, 80, cfg.Port)
+	assert.Equal(t, "prod-eu", cfg.Host)
+	assert.Equal(t, "eu", cfg.Region)
+	assert.True(t, cfg.Canary)
+
+	assert.Equal(t, []string{
+		filepath.Join(dir, "base.yaml"),
+		filepath.Join(dir, "prod.yaml"),
+		filepath.Join(dir, "prod-eu.yaml"),
+		filepath.Join(dir, "prod-eu-canary.yaml"),
+	}, loader.LoadedFiles())
+}
+
+func TestProfiles_AppProfilesOverridesAppEnv(t *testing.T) {
+	dir := writeProfileFiles(t)
+	t.Setenv("APP_ENV", "prod,prod-eu")
+	t.Setenv("APP_PROFILES", "prod")
+
+	loader, err := NewE(WithConfigPaths(dir))
+	require.NoError(t, err)
+
+	var cfg profileConfig
+	require.NoError(t, loader.Bind(&cfg))
+	assert.Equal(t, "prod", cfg.Host)
+	assert.Equal(t, "none", cfg.Region)
+}
+
+func TestProfiles_MissingOverlaySkipped(t *testing.T) {
+	dir := writeProfileFiles(t)
+	t.Setenv("APP_PROFILES", "prod,does-not-exist")
+
+	loader, err := NewE(WithConfigPaths(dir))
+	require.NoError(t, err)
+	assert.Len(t, loader.LoadedFiles(), 2)
+}
+
+func TestInclude(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "common"), 0o755))
+	writeFile(t, dir, "common/net.yaml", "app:\n  host: included\n  port: 1\n")
+	writeFile(t, dir, "common/region.json", `{"app": {"region": "us"}}`)
+	writeFile(t, dir, "base.yaml", "include:\n  - common/net.yaml\n  - common/region.json\napp:\n  port: 2\n")
+
+	loader, err := NewE(WithConfigPaths(dir))
+	require.NoError(t, err)
+
+	var cfg profileConfig
+	require.NoError(t, loader.Bind(&cfg))
+	assert.Equal(t, "included", cfg.Host)
+	assert.Equal(t, "us", cfg.Region)
+	// The including file overrides what it includes
+	assert.Equal(t, 2, cfg.Port)
+
+	assert.Equal(t, []string{
+		filepath.Join(dir, "common/net.yaml"),
+		filepath.Join(dir, "common/region.json"),
+		filepath.Join(dir, "base.yaml"),
+	}, loader.LoadedFiles())
+}
+
+func TestInclude_SingleString(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "shared.yaml", "app:\n  host: shared\n")
+	writeFile(t, dir, "base.yaml", "include: shared.yaml\n")
+
+	loader, err := NewE(WithConfigPaths(dir))
+	require.NoError(t, err)
+
+	var cfg profileConfig
+	require.NoError(t, loader.Bind(&cfg))
+	assert.Equal(t, "shared", cfg.Host)
+}
+
+func TestInclude_Errors(t *testing.T) {
+	t.Run("cycle", func(t *testing.T) {
+		dir := t.TempDir()
+		writeFile(t, dir, "base.yaml", "include: a.yaml\n")
+		writeFile(t, dir, "a.yaml", "include: b.yaml\n")
+		writeFile(t, dir, "b.yaml", "include: a.yaml\n")
+
+		_, err := NewE(WithConfigPaths(dir))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "config include cycle")
+		assert.Contains(t, err.Error(), "a.yaml -> ")
+	})
+
+	t.Run("self include", func(t *testing.T) {
+		dir := t.TempDir()
+		writeFile(t, dir, "base.yaml", "include: base.yaml\n")
+
+		_, err := NewE(WithConfigPaths(dir))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "config include cycle")
+	})
+
+	t.Run("missing include", func(t *testing.T) {
+		dir := t.TempDir()
+		writeFile(t, dir, "base.yaml", "include: nope.yaml\n")
+
+		_, err := NewE(WithConfigPaths(dir))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "required config file")
+	})
+
+	t.Run("invalid include value", func(t *testing.T) {
+		dir := t.TempDir()
+		writeFile(t, dir, "base.yaml", "include:\n  file: x.yaml\n")
+
+		_, err := NewE(WithConfigPaths(dir))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "invalid include")
+	})
+}
@@ -0,0 +1,108 @@
+package configx
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+
+	"go.uber.org/fx"
+)
+
+// Provide returns an fx.Option that binds the config struct T with the
+// application's Loader and supplies it as both T and *T. The *T value is the
+// bound instance; T is a copy of it. Once bound, T is recorded in the
+// loader's registry returned by BoundConfigs.
+//
+// It replaces hand-written constructors such as:
+//
+//	func NewDBConfig(loader configx.Loader) (DBConfig, error) {
+//	    var c DBConfig
+//	    return c, loader.Bind(&c)
+//	}
+//
+// Example:
+//
+//	fx.New(
+//	    fx.Provide(configx.NewE),
+//	    configx.Provide[DBConfig](),
+//	    fx.Invoke(func(c DBConfig) { ... }),
+//	)
+//
+// T must be a struct type whose Prefix method has a value receiver.
+func Provide[T Configurable]() fx.Option {
+	typ := reflect.TypeFor[T]()
+	if typ.Kind() != reflect.Struct {
+		return fx.Error(fmt.Errorf("configx.Provide: %s must be a struct type", typ))
+	}
+	return fx.Provide(
+		func(loader Loader) (*T, error) {
+			c := new(T)
+			if err := loader.Bind(any(c).(Configurable)); err != nil {
+				return nil, fmt.Errorf("failed to bind %s: %w", typ, err)
+			}
+			if vl, ok := loader.(*viperLoader); ok {
+				vl.configs.bound(typ, (*c).Prefix(), vl.key((*c).Prefix()), c)
+			}
+			return c, nil
+		},
+		func(c *T) T { return *c },
+	)
+}
+
+// BoundConfig describes a config struct bound through Provide.
+type BoundConfig struct {
+	// Type is the config struct type.
+	Type reflect.Type
+	// Prefix is the value of the type's Prefix method.
+	Prefix string
+	// Key is the full config key the struct was bound from, including the
+	// scope of a Sub loader.
+	Key string
+	// Value is a pointer to the bound instance.
+	Value any
+}
+
+// BoundConfigs returns the config types bound through Provide with loader or
+// the loaders returned by its Sub method, in binding order. Tooling such as
+// schema generators and config dumps use it to discover every config of an
+// application.
+func BoundConfigs(loader Loader) []BoundConfig {
+	vl, ok := loader.(*viperLoader)
+	if !ok {
+		return nil
+	}
+	r := vl.configs
+	r.RLock()
+	defer r.RUnlock()
+
+	configs := make([]BoundConfig, len(r.configs))
+	for i, c := range r.configs {
+		configs[i] = *c
+	}
+	return configs
+}
+
+// configRegistry records the configs bound through Provide with one loader.
+type configRegistry struct {
+	sync.RWMutex
+	configs []*BoundConfig
+	index   map[reflect.Type]*BoundConfig
+}
+
+func newConfigRegistry() *configRegistry {
+	return &configRegistry{index: map[reflect.Type]*BoundConfig{}}
+}
+
+// bound records value as the bound instance of typ, replacing an earlier one.
+func (r *configRegistry) bound(typ reflect.Type, prefix, key string, value any) {
+	r.Lock()
+	defer r.Unlock()
+
+	c, ok := r.index[typ]
+	if !ok {
+		c = &BoundConfig{Type: typ}
+		r.configs = append(r.configs, c)
+		r.index[typ] = c
+	}
+	c.Prefix, c.Key, c.Value = prefix, key, value
+}
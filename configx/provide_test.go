@@ -0,0 +1,99 @@
+package configx
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/fx"
+	"go.uber.org/fx/fxtest"
+)
+
+type provideConfig struct {
+	Host string `mapstructure:"host" default:"localhost"`
+	Port int    `mapstructure:"port" default:"8080" validate:"min=1"`
+}
+
+func (provideConfig) Prefix() string { return "provide" }
+
+type providePtrConfig struct{}
+
+func (*providePtrConfig) Prefix() string { return "ptr" }
+
+type provideUnusedConfig struct{}
+
+func (provideUnusedConfig) Prefix() string { return "unused" }
+
+func TestProvide_SuppliesValueAndPointer(t *testing.T) {
+	var (
+		val provideConfig
+		ptr *provideConfig
+	)
+	app := fxtest.New(t,
+		fx.Supply(fx.Annotate(newTestLoader(t, "provide:\n  host: db\n"), fx.As(new(Loader)))),
+		Provide[provideConfig](),
+		fx.Populate(&val, &ptr),
+	)
+	defer app.RequireStart().RequireStop()
+
+	assert.Equal(t, provideConfig{Host: "db", Port: 8080}, val)
+	require.NotNil(t, ptr)
+	assert.Equal(t, val, *ptr)
+}
+
+func TestProvide_BindError(t *testing.T) {
+	app := fx.New(
+		fx.NopLogger,
+		fx.Supply(fx.Annotate(newTestLoader(t, "provide:\n  port: -1\n"), fx.As(new(Loader)))),
+		Provide[provideConfig](),
+		fx.Invoke(func(provideConfig) {}),
+	)
+	require.Error(t, app.Err())
+	assert.Contains(t, app.Err().Error(), "failed to bind configx.provideConfig")
+	assert.Contains(t, app.Err().Error(), "validation failed")
+}
+
+func TestProvide_RequiresStructType(t *testing.T) {
+	app := fx.New(fx.NopLogger, Provide[*providePtrConfig]())
+	require.Error(t, app.Err())
+	assert.Contains(t, app.Err().Error(), "must be a struct type")
+}
+
+func TestBoundConfigs(t *testing.T) {
+	root := newTestLoader(t, "app:\n  provide:\n    port: 9000\n")
+	other := newTestLoader(t, "provide:\n  port: 1\n")
+
+	var ptr *provideConfig
+	app := fxtest.New(t,
+		fx.Supply(fx.Annotate(root.Sub("app"), fx.As(new(Loader)))),
+		Provide[provideConfig](),
+		Provide[provideUnusedConfig](),
+		fx.Populate(&ptr),
+	)
+	defer app.RequireStart().RequireStop()
+
+	// Provided but never used, so never bound
+	found := BoundConfigs(root)
+	require.Len(t, found, 1)
+	assert.Equal(t, reflect.TypeFor[provideConfig](), found[0].Type)
+	assert.Equal(t, "provide", found[0].Prefix)
+	assert.Equal(t, "app.provide", found[0].Key)
+	assert.Same(t, ptr, found[0].Value)
+	assert.Equal(t, 9000, ptr.Port)
+
+	// Configs are recorded per loader
+	assert.Empty(t, BoundConfigs(other))
+	assert.Nil(t, BoundConfigs(nil))
+
+	var again *provideConfig
+	app2 := fxtest.New(t,
+		fx.Supply(fx.Annotate(other, fx.As(new(Loader)))),
+		Provide[provideConfig](),
+		fx.Populate(&again),
+	)
+	defer app2.RequireStart().RequireStop()
+	require.Len(t, BoundConfigs(other), 1)
+	assert.Same(t, again, BoundConfigs(other)[0].Value)
+	assert.Same(t, ptr, BoundConfigs(root)[0].Value)
+}
@@ -9,8 +9,8 @@ import (
 // New builds the Fx app with default Gostratum core modules.
 func New(opts ...fx.Option) *fx.App {
 	return fx.New(
-		fx.Provide(configx.New),
-		fx.Provide(configx.NewConfig),
+		fx.Provide(configx.NewE),
+		configx.Provide[configx.Config](),
 		logx.Module(),
 		fx.Provide(NewHealthRegistry),
 		fx.Options(opts...),
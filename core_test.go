@@ -3,6 +3,9 @@ package core_test
 import (
 	"context"
 	"errors"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/gostratum/core"
@@ -33,6 +36,23 @@ func TestNewProvidesCoreConfig(t *testing.T) {
 	app.Stop(context.Background())
 }
 
+// TestNewFailsOnMalformedConfig verifies that a config file parse error fails
+// application startup and names the offending file.
+func TestNewFailsOnMalformedConfig(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "base.yaml"), []byte("core:\n  logger: [\n"), 0o644); err != nil {
+		t.Fatalf("failed to write base.yaml: %v", err)
+	}
+	t.Setenv("CONFIG_PATHS", dir)
+
+	app := core.New()
+	if err := app.Err(); err == nil {
+		t.Fatal("expected startup error for malformed config")
+	} else if !strings.Contains(err.Error(), "base.yaml") {
+		t.Fatalf("expected error to name base.yaml, got %v", err)
+	}
+}
+
 // TestHealthRegistry verifies basic health check registration and aggregation.
 func TestHealthRegistry(t *testing.T) {
 	registry := core.NewHealthRegistry()
@@ -1,11 +1,16 @@
 package core
 
-import "errors"
+import (
+	"errors"
+
+	"github.com/gostratum/core/configx"
+)
 
 // Common errors for the core package.
 var (
 	// ErrConfigNotFound is returned when a configuration key is not found.
-	ErrConfigNotFound = errors.New("config key not found")
+	// It is the same value as configx.ErrConfigNotFound.
+	ErrConfigNotFound = configx.ErrConfigNotFound
 	// ErrHealthCheckFailed is returned when a health check fails.
 	ErrHealthCheckFailed = errors.New("health check failed")
 )
@@ -4,6 +4,7 @@ package logx
 import (
 	"context"
 	"reflect"
+	"slices"
 
 	"go.uber.org/zap"
 )
@@ -18,16 +19,58 @@ type Logger interface {
 	Warn(msg string, fields ...Field)
 	Error(msg string, fields ...Field)
 	With(fields ...Field) Logger
+	// Named returns a child logger whose name is joined to the parent's with
+	// a dot, e.g. "http" then "access" gives "http.access". Per-name levels
+	// are set with core.logger.levels.
+	Named(name string) Logger
 }
 
 // zapAdapter implements Logger by forwarding to *zap.Logger
-type zapAdapter struct{ l *zap.Logger }
+type zapAdapter struct {
+	l *zap.Logger
+	// ctx records the context fields (see Ctx and FromContext) l carries, so
+	// a logger stored back with WithContext logs each key once.
+	ctx *ctxFields
+}
+
+// ctxFields are the context fields attached to base, one per key, and the
+// With and Named calls made after them. A field whose value changes is
+// replaced by rebuilding the logger from base.
+type ctxFields struct {
+	base   *zap.Logger
+	fields []Field
+	ops    []func(*zap.Logger) *zap.Logger
+}
+
+// then returns c with op recorded, or nil when c is nil.
+func (c *ctxFields) then(op func(*zap.Logger) *zap.Logger) *ctxFields {
+	if c == nil {
+		return nil
+	}
+	return &ctxFields{base: c.base, fields: c.fields, ops: append(slices.Clip(c.ops), op)}
+}
+
+// build returns base with the fields and recorded calls applied.
+func (c *ctxFields) build() *zap.Logger {
+	l := c.base.With(c.fields...)
+	for _, op := range c.ops {
+		l = op(l)
+	}
+	return l
+}
 
 func (z *zapAdapter) Debug(msg string, fields ...Field) { z.l.Debug(msg, fields...) }
 func (z *zapAdapter) Info(msg string, fields ...Field)  { z.l.Info(msg, fields...) }
 func (z *zapAdapter) Warn(msg string, fields ...Field)  { z.l.Warn(msg, fields...) }
 func (z *zapAdapter) Error(msg string, fields ...Field) { z.l.Error(msg, fields...) }
-func (z *zapAdapter) With(fields ...Field) Logger       { return &zapAdapter{l: z.l.With(fields...)} }
+func (z *zapAdapter) With(fields ...Field) Logger {
+	op := func(l *zap.Logger) *zap.Logger { return l.With(fields...) }
+	return &zapAdapter{l: op(z.l), ctx: z.ctx.then(op)}
+}
+func (z *zapAdapter) Named(name string) Logger {
+	op := func(l *zap.Logger) *zap.Logger { return l.Named(name) }
+	return &zapAdapter{l: op(z.l), ctx: z.ctx.then(op)}
+}
 
 // ProvideAdapter allows Fx consumers to get the Logger interface.
 func ProvideAdapter(l *zap.Logger) Logger { return &zapAdapter{l: l} }
@@ -45,8 +88,69 @@ func WithContext(ctx context.Context, l Logger) context.Context {
 	return context.WithValue(ctx, ctxKey, l)
 }
 
-// FromContext extracts a Logger from context or returns a nop logger adapted from zap.NewNop().
+// FromContext extracts a Logger from context or returns a nop logger adapted
+// from zap.NewNop(). Fields added with AddFields are attached to it. When the
+// stored logger was itself derived from FromContext or Ctx, each key is still
+// logged once, with its latest value:
+//
+//	ctx = logx.WithContext(ctx, logx.FromContext(ctx).With(logx.String("handler", "orders")))
+//	ctx = logx.AddFields(ctx, logx.String("user_id", id))
+//	logx.FromContext(ctx).Info("done") // user_id is logged once
 func FromContext(ctx context.Context) Logger {
+	return withContextFields(loggerFrom(ctx), fieldsFrom(ctx))
+}
+
+// withContextFields attaches the context fields l does not carry yet,
+// replacing those it carries with another value.
+func withContextFields(l Logger, fields []Field) Logger {
+	if len(fields) == 0 {
+		return l
+	}
+	z, ok := l.(*zapAdapter)
+	if !ok {
+		return l.With(fields...)
+	}
+	if z.ctx == nil {
+		return &zapAdapter{l: z.l.With(fields...), ctx: &ctxFields{base: z.l, fields: fields}}
+	}
+
+	merged := slices.Clone(z.ctx.fields)
+	var add []Field
+	changed := false
+	for _, f := range fields {
+		i := slices.IndexFunc(merged, func(c Field) bool { return c.Key == f.Key })
+		switch {
+		case i < 0:
+			merged = append(merged, f)
+			add = append(add, f)
+		case !sameField(merged[i], f):
+			merged[i] = f
+			changed = true
+		}
+	}
+	if !changed && len(add) == 0 {
+		return z
+	}
+	next := &ctxFields{base: z.ctx.base, fields: merged, ops: z.ctx.ops}
+	if changed {
+		return &zapAdapter{l: next.build(), ctx: next}
+	}
+	return &zapAdapter{l: z.l.With(add...), ctx: next}
+}
+
+// sameField reports whether a and b are equal. Fields holding values that
+// cannot be compared, such as a net.IP Stringer, are never equal.
+func sameField(a, b Field) (same bool) {
+	defer func() {
+		if recover() != nil {
+			same = false
+		}
+	}()
+	return a.Equals(b)
+}
+
+// loggerFrom returns the logger of ctx without the added fields.
+func loggerFrom(ctx context.Context) Logger {
 	if ctx == nil {
 		return &zapAdapter{l: zap.NewNop()}
 	}
@@ -98,6 +202,22 @@ func Float64(key string, val float64) zap.Field { return zap.Float64(key, val) }
 // it will be automatically sanitized before logging to prevent accidental exposure
 // of secrets (passwords, API keys, tokens, DSNs, etc.).
 //
+// Other values are redacted field by field, recursing through structs,
+// pointers, slices and maps:
+//
+//	type User struct {
+//	    Name     string
+//	    Password string              // redacted: the name looks like a secret
+//	    SSN      string   `log:"redact"`
+//	    Session  *Session `log:"-"` // left out
+//	}
+//
+// Struct fields tagged log:"redact" and fields or map keys whose name looks
+// like a secret (see SanitizeMap) are logged as "[redacted]"; fields tagged
+// log:"-" are left out. Field names follow json tags, as in zap's own
+// encoding, and nested Sanitizable values are sanitized. The plan of each
+// type is computed once, so types without secrets cost a cache lookup.
+//
 // This provides defense-in-depth security: developers don't need to remember to
 // sanitize configs manually - it happens automatically.
 //
@@ -122,7 +242,7 @@ func Any(key string, val any) zap.Field {
 		// Safe to call Sanitize()
 		return zap.Any(key, s.Sanitize())
 	}
-	return zap.Any(key, val)
+	return redactedField(key, val)
 }
 
 func Duration(key string, val any) zap.Field { return zap.Any(key, val) }
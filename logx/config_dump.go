@@ -0,0 +1,289 @@
+package logx
+
+import (
+	"context"
+	"encoding"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/gostratum/core/configx"
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+	"go.yaml.in/yaml/v3"
+)
+
+// EffectiveConfig returns the configuration the application runs with: every
+// config struct bound through configx.Provide with loader (see
+// configx.BoundConfigs), nested under its config key.
+//
+// Secrets are redacted as by Any, at any depth: values implementing
+// Sanitizable are sanitized, fields tagged log:"-" are left out, and fields
+// tagged log:"redact" or whose field or map key looks like a secret are
+// masked. Secrets inside strings, such as the password of a DSN, are masked
+// by the built-in scrubbing patterns (see ScrubConfig).
+//
+// Durations, sizes, URLs and other types implementing fmt.Stringer or
+// encoding.TextMarshaler are rendered as strings.
+func EffectiveConfig(loader configx.Loader) map[string]any {
+	out := map[string]any{}
+	for _, c := range configx.BoundConfigs(loader) {
+		parts := strings.Split(c.Key, ".")
+		m := out
+		for _, p := range parts[:len(parts)-1] {
+			next, ok := m[p].(map[string]any)
+			if !ok {
+				next = map[string]any{}
+				m[p] = next
+			}
+			m = next
+		}
+		m[parts[len(parts)-1]] = configValue(reflect.ValueOf(c.Value), true)
+	}
+	return out
+}
+
+// EffectiveConfigHandler serves EffectiveConfig for debugging. The response
+// is JSON unless the request asks for YAML with ?format=yaml or an Accept
+// header containing "yaml".
+//
+// The handler exposes internal settings; mount it on an admin listener only.
+//
+// Example:
+//
+//	mux.Handle("/debug/config", logx.EffectiveConfigHandler(loader))
+func EffectiveConfigHandler(loader configx.Loader) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			w.Header().Set("Allow", "GET, HEAD")
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		cfg := EffectiveConfig(loader)
+		format := strings.ToLower(r.URL.Query().Get("format"))
+		if format == "" && strings.Contains(r.Header.Get("Accept"), "yaml") {
+			format = "yaml"
+		}
+
+		switch format {
+		case "", "json":
+			w.Header().Set("Content-Type", "application/json")
+			enc := json.NewEncoder(w)
+			enc.SetIndent("", "  ")
+			_ = enc.Encode(cfg)
+		case "yaml", "yml":
+			w.Header().Set("Content-Type", "application/yaml")
+			_ = yaml.NewEncoder(w).Encode(cfg)
+		default:
+			http.Error(w, fmt.Sprintf("unsupported format %q", format), http.StatusBadRequest)
+		}
+	})
+}
+
+// logEffectiveConfig logs EffectiveConfig once on start, after all
+// constructors (and so all config bindings) have run.
+func logEffectiveConfig(lc fx.Lifecycle, l *zap.Logger, c LoggerConfig, loader configx.Loader) {
+	if !c.DumpConfig {
+		return
+	}
+	lc.Append(fx.Hook{
+		OnStart: func(context.Context) error {
+			l.Info("effective configuration", zap.Any("config", EffectiveConfig(loader)))
+			return nil
+		},
+	})
+}
+
+// warnPrefixConflicts logs a warning on start for every pair of config types
+// that bind duplicate or overlapping prefixes (see configx.PrefixConflicts).
+func warnPrefixConflicts(lc fx.Lifecycle, l *zap.Logger, loader configx.Loader) {
+	lc.Append(fx.Hook{
+		OnStart: func(context.Context) error {
+			for _, c := range configx.PrefixConflicts(loader) {
+				fields := []zap.Field{
+					zap.String("prefix", c.Prefix),
+					zap.Strings("owners", typeStrings(c.Types)),
+				}
+				if c.Nested != "" {
+					fields = append(fields,
+						zap.String("nested_prefix", c.Nested),
+						zap.Strings("nested_owners", typeStrings(c.NestedTypes)),
+					)
+				}
+				l.Warn("conflicting config prefixes: "+c.String(), fields...)
+			}
+			return nil
+		},
+	})
+}
+
+// routeLoaderWarnings makes the loader log its warnings, such as deprecated
+// config keys, through l while the app runs. Warnings of configs bound before
+// the logger was built, including LoggerConfig itself, are logged right away.
+func routeLoaderWarnings(lc fx.Lifecycle, l *slog.Logger, loader configx.Loader) {
+	configx.SetLogger(loader, l)
+	lc.Append(fx.Hook{
+		OnStop: func(context.Context) error {
+			// The outputs of l are closed on stop
+			configx.SetLogger(loader, nil)
+			return nil
+		},
+	})
+}
+
+func typeStrings(types []reflect.Type) []string {
+	out := make([]string, len(types))
+	for i, t := range types {
+		out[i] = t.String()
+	}
+	return out
+}
+
+var (
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+	stringerType      = reflect.TypeOf((*fmt.Stringer)(nil)).Elem()
+)
+
+// dumpScrubber masks secrets inside dumped strings, whether or not scrubbing
+// is enabled for log entries.
+var dumpScrubber, _ = newScrubber(ScrubConfig{Enabled: true})
+
+// configValue converts a config value into maps, slices and scalars keyed by
+// mapstructure names, with secrets redacted. Sanitizable values are replaced
+// by their sanitized form when sanitize is set.
+func configValue(v reflect.Value, sanitize bool) any {
+	if !v.IsValid() {
+		return nil
+	}
+	if sanitize && v.CanInterface() {
+		if s, ok := v.Interface().(Sanitizable); ok && !(v.Kind() == reflect.Pointer && v.IsNil()) {
+			// The sanitized copy is usually Sanitizable itself
+			return configValue(reflect.ValueOf(s.Sanitize()), false)
+		}
+		if v.CanAddr() {
+			if s, ok := v.Addr().Interface().(Sanitizable); ok {
+				return configValue(reflect.ValueOf(s.Sanitize()), false)
+			}
+		}
+	}
+
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if v.IsNil() {
+			return nil
+		}
+		return configValue(v.Elem(), sanitize)
+	}
+
+	if s, ok := stringValue(v); ok {
+		return dumpScrubber.scrub(s)
+	}
+
+	switch v.Kind() {
+	case reflect.Struct:
+		m := map[string]any{}
+		structValue(v, m, sanitize)
+		return m
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && v.IsNil() {
+			return nil
+		}
+		if v.Type().Elem().Kind() == reflect.Uint8 && v.Kind() == reflect.Slice {
+			return base64.StdEncoding.EncodeToString(v.Bytes())
+		}
+		out := make([]any, v.Len())
+		for i := range out {
+			out[i] = configValue(v.Index(i), sanitize)
+		}
+		return out
+	case reflect.Map:
+		if v.IsNil() {
+			return nil
+		}
+		r := activeRedactor.Load()
+		out := make(map[string]any, v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			key := fmt.Sprint(iter.Key().Interface())
+			if r.isSecret(key) {
+				out[key] = r.maskReflect(iter.Value())
+				continue
+			}
+			out[key] = configValue(iter.Value(), sanitize)
+		}
+		return out
+	case reflect.String:
+		return dumpScrubber.scrub(v.String())
+	default:
+		if v.CanInterface() {
+			return v.Interface()
+		}
+		return nil
+	}
+}
+
+func structValue(v reflect.Value, m map[string]any, sanitize bool) {
+	r := activeRedactor.Load()
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name, opts, _ := strings.Cut(f.Tag.Get("mapstructure"), ",")
+		if name == "-" || f.Tag.Get("log") == "-" {
+			continue
+		}
+		if strings.Contains(opts, "squash") && v.Field(i).Kind() == reflect.Struct {
+			structValue(v.Field(i), m, sanitize)
+			continue
+		}
+		if !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = strings.ToLower(f.Name)
+		}
+		if r.isSecretField(f, name) {
+			m[name] = r.maskReflect(v.Field(i))
+			continue
+		}
+		m[name] = configValue(v.Field(i), sanitize)
+	}
+}
+
+// stringValue renders types such as time.Duration, configx.ByteSize,
+// url.URL and net.IP as strings.
+func stringValue(v reflect.Value) (string, bool) {
+	if !v.CanInterface() {
+		return "", false
+	}
+	if v.Kind() != reflect.Struct && v.Kind() != reflect.Slice && v.Kind() != reflect.Map && v.Type().PkgPath() == "" {
+		// Plain builtin types
+		return "", false
+	}
+
+	for _, val := range []reflect.Value{v, addr(v)} {
+		if !val.IsValid() {
+			continue
+		}
+		if val.Type().Implements(textMarshalerType) {
+			if b, err := val.Interface().(encoding.TextMarshaler).MarshalText(); err == nil {
+				return string(b), true
+			}
+		}
+		if val.Type().Implements(stringerType) {
+			return val.Interface().(fmt.Stringer).String(), true
+		}
+	}
+	return "", false
+}
+
+func addr(v reflect.Value) reflect.Value {
+	if v.CanAddr() {
+		return v.Addr()
+	}
+	return reflect.Value{}
+}
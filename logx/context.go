@@ -0,0 +1,239 @@
+package logx
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"sync"
+
+	"go.uber.org/zap"
+)
+
+// ContextExtractor returns the fields to log for values found in ctx, such
+// as trace or request IDs, or nil when there are none.
+type ContextExtractor func(ctx context.Context) []Field
+
+var contextExtractors = struct {
+	sync.RWMutex
+	names []string
+	m     map[string]ContextExtractor
+}{
+	names: []string{"traceparent", "request_id"},
+	m: map[string]ContextExtractor{
+		"traceparent": traceContextFields,
+		"request_id":  requestIDFields,
+	},
+}
+
+// RegisterContextExtractor registers a named extractor used by Ctx and
+// ContextFields, replacing any extractor with the same name. Extractors run
+// in registration order. Built-in extractors: traceparent (trace_id and
+// span_id from WithTraceContext) and request_id (from WithRequestID).
+//
+// Tracing libraries plug in without logx importing them, e.g.:
+//
+//	logx.RegisterContextExtractor("traceparent", func(ctx context.Context) []logx.Field {
+//	    sc := trace.SpanContextFromContext(ctx)
+//	    if !sc.IsValid() {
+//	        return nil
+//	    }
+//	    return []logx.Field{
+//	        logx.String("trace_id", sc.TraceID().String()),
+//	        logx.String("span_id", sc.SpanID().String()),
+//	    }
+//	})
+func RegisterContextExtractor(name string, e ContextExtractor) {
+	if name = strings.TrimSpace(name); name == "" || e == nil {
+		return
+	}
+	contextExtractors.Lock()
+	defer contextExtractors.Unlock()
+	if _, ok := contextExtractors.m[name]; !ok {
+		contextExtractors.names = append(contextExtractors.names, name)
+	}
+	contextExtractors.m[name] = e
+}
+
+// ContextFields returns the fields of every registered extractor for ctx,
+// followed by the fields added with AddFields. Use it with loggers that are
+// not carried by the context:
+//
+//	s.log.With(logx.ContextFields(ctx)...).Info("order placed")
+func ContextFields(ctx context.Context) []Field {
+	if ctx == nil {
+		return nil
+	}
+	contextExtractors.RLock()
+	extractors := make([]ContextExtractor, len(contextExtractors.names))
+	for i, name := range contextExtractors.names {
+		extractors[i] = contextExtractors.m[name]
+	}
+	contextExtractors.RUnlock()
+
+	var fields []Field
+	for _, e := range extractors {
+		fields = append(fields, e(ctx)...)
+	}
+	return append(fields, fieldsFrom(ctx)...)
+}
+
+// Ctx returns the logger of ctx (see FromContext) with the trace, request
+// and other IDs found in ctx and the fields added with AddFields attached.
+//
+//	logx.Ctx(ctx).Info("order placed", logx.String("order_id", id))
+//	// {"msg": "order placed", "trace_id": "4bf9...", "span_id": "00f0...", "request_id": "...", ...}
+//
+// As with FromContext, a key the stored logger already carries is logged
+// once, with its latest value.
+func Ctx(ctx context.Context) Logger {
+	return withContextFields(loggerFrom(ctx), ContextFields(ctx))
+}
+
+type fieldsCtxKeyType struct{}
+
+var fieldsCtxKey = fieldsCtxKeyType{}
+
+// AddFields returns a new context carrying fields in addition to those
+// added to ctx before; a field replaces an earlier one with the same key.
+// FromContext, Ctx and ContextFields include them, so request-scoped values
+// are logged by every downstream logger:
+//
+//	ctx = logx.AddFields(ctx, logx.String("user_id", user.ID))
+//	logx.FromContext(ctx).Info("cart updated") // includes user_id
+//
+// ctx is not modified, so contexts derived from it concurrently (e.g. in
+// other goroutines) do not see each other's fields.
+func AddFields(ctx context.Context, fields ...Field) context.Context {
+	if len(fields) == 0 {
+		return ctx
+	}
+	existing := fieldsFrom(ctx)
+	merged := make([]Field, 0, len(existing)+len(fields))
+	for _, f := range existing {
+		if !hasKey(fields, f.Key) {
+			merged = append(merged, f)
+		}
+	}
+	for i, f := range fields {
+		// Within fields too, the last one wins
+		if !hasKey(fields[i+1:], f.Key) {
+			merged = append(merged, f)
+		}
+	}
+	return context.WithValue(ctx, fieldsCtxKey, merged)
+}
+
+// fieldsFrom returns the fields added with AddFields. The slice is shared
+// and must not be modified.
+func fieldsFrom(ctx context.Context) []Field {
+	if ctx == nil {
+		return nil
+	}
+	fields, _ := ctx.Value(fieldsCtxKey).([]Field)
+	return fields
+}
+
+func hasKey(fields []Field, key string) bool {
+	for _, f := range fields {
+		if f.Key == key {
+			return true
+		}
+	}
+	return false
+}
+
+// TraceContext is the W3C trace context of a request.
+type TraceContext struct {
+	// TraceID is 32 lower-case hex digits and SpanID (the parent-id of the
+	// traceparent header) 16.
+	TraceID string
+	SpanID  string
+	Sampled bool
+}
+
+// ErrInvalidTraceparent is returned by ParseTraceparent for malformed
+// headers.
+var ErrInvalidTraceparent = errors.New("invalid traceparent")
+
+// ParseTraceparent parses a W3C traceparent header such as
+// "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01".
+func ParseTraceparent(header string) (TraceContext, error) {
+	h := strings.ToLower(strings.TrimSpace(header))
+	// Later versions may append fields after the flags
+	if len(h) < 55 || h[2] != '-' || h[35] != '-' || h[52] != '-' || len(h) > 55 && h[55] != '-' {
+		return TraceContext{}, ErrInvalidTraceparent
+	}
+	version, traceID, spanID, flags := h[0:2], h[3:35], h[36:52], h[53:55]
+	if !isHex(version) || version == "ff" || version == "00" && len(h) != 55 ||
+		!isHex(traceID) || traceID == strings.Repeat("0", 32) ||
+		!isHex(spanID) || spanID == strings.Repeat("0", 16) ||
+		!isHex(flags) {
+		return TraceContext{}, ErrInvalidTraceparent
+	}
+	b, _ := hex.DecodeString(flags)
+	return TraceContext{TraceID: traceID, SpanID: spanID, Sampled: b[0]&1 == 1}, nil
+}
+
+func isHex(s string) bool {
+	_, err := hex.DecodeString(s)
+	return err == nil
+}
+
+type traceCtxKeyType struct{}
+type requestIDCtxKeyType struct{}
+
+var (
+	traceCtxKey     = traceCtxKeyType{}
+	requestIDCtxKey = requestIDCtxKeyType{}
+)
+
+// WithTraceContext returns a new context carrying tc.
+func WithTraceContext(ctx context.Context, tc TraceContext) context.Context {
+	return context.WithValue(ctx, traceCtxKey, tc)
+}
+
+// WithTraceparent parses header and returns a new context carrying its
+// trace context, or ctx unchanged when the header is missing or invalid.
+//
+//	ctx := logx.WithTraceparent(r.Context(), r.Header.Get("traceparent"))
+func WithTraceparent(ctx context.Context, header string) context.Context {
+	tc, err := ParseTraceparent(header)
+	if err != nil {
+		return ctx
+	}
+	return WithTraceContext(ctx, tc)
+}
+
+// TraceContextFrom returns the trace context stored by WithTraceContext.
+func TraceContextFrom(ctx context.Context) (TraceContext, bool) {
+	tc, ok := ctx.Value(traceCtxKey).(TraceContext)
+	return tc, ok
+}
+
+// WithRequestID returns a new context carrying the request ID.
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDCtxKey, id)
+}
+
+// RequestIDFrom returns the request ID stored by WithRequestID.
+func RequestIDFrom(ctx context.Context) (string, bool) {
+	id, ok := ctx.Value(requestIDCtxKey).(string)
+	return id, ok && id != ""
+}
+
+func traceContextFields(ctx context.Context) []Field {
+	tc, ok := TraceContextFrom(ctx)
+	if !ok {
+		return nil
+	}
+	return []Field{zap.String("trace_id", tc.TraceID), zap.String("span_id", tc.SpanID)}
+}
+
+func requestIDFields(ctx context.Context) []Field {
+	id, ok := RequestIDFrom(ctx)
+	if !ok {
+		return nil
+	}
+	return []Field{zap.String("request_id", id)}
+}
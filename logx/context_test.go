@@ -0,0 +1,209 @@
+package logx
+
+import (
+	"context"
+	"net"
+	"slices"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestParseTraceparent(t *testing.T) {
+	tc, err := ParseTraceparent("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	require.NoError(t, err)
+	assert.Equal(t, TraceContext{TraceID: "4bf92f3577b34da6a3ce929d0e0e4736", SpanID: "00f067aa0ba902b7", Sampled: true}, tc)
+
+	tc, err = ParseTraceparent(" 00-4BF92F3577B34DA6A3CE929D0E0E4736-00F067AA0BA902B7-00 ")
+	require.NoError(t, err)
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", tc.TraceID)
+	assert.False(t, tc.Sampled)
+
+	// Future versions may carry extra fields
+	_, err = ParseTraceparent("01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra")
+	assert.NoError(t, err)
+
+	for _, h := range []string{
+		"",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra",
+		"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		"00-00000000000000000000000000000000-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e473g-00f067aa0ba902b7-01",
+		"00_4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		"01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01x",
+	} {
+		_, err := ParseTraceparent(h)
+		assert.ErrorIs(t, err, ErrInvalidTraceparent, h)
+	}
+}
+
+func TestContextValues(t *testing.T) {
+	ctx := context.Background()
+	_, ok := TraceContextFrom(ctx)
+	assert.False(t, ok)
+	_, ok = RequestIDFrom(WithRequestID(ctx, ""))
+	assert.False(t, ok)
+
+	assert.Equal(t, ctx, WithTraceparent(ctx, "garbage"))
+	tc, ok := TraceContextFrom(WithTraceparent(ctx, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"))
+	assert.True(t, ok)
+	assert.Equal(t, "00f067aa0ba902b7", tc.SpanID)
+
+	id, ok := RequestIDFrom(WithRequestID(ctx, "req-1"))
+	assert.True(t, ok)
+	assert.Equal(t, "req-1", id)
+}
+
+func TestCtx(t *testing.T) {
+	core, logs := observer.New(zapcore.InfoLevel)
+	ctx := WithContext(context.Background(), ProvideAdapter(zap.New(core)))
+	ctx = WithTraceparent(ctx, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	ctx = WithRequestID(ctx, "req-1")
+
+	Ctx(ctx).Info("handled", String("k", "v"))
+	require.Equal(t, 1, logs.Len())
+	assert.Equal(t, map[string]any{
+		"trace_id":   "4bf92f3577b34da6a3ce929d0e0e4736",
+		"span_id":    "00f067aa0ba902b7",
+		"request_id": "req-1",
+		"k":          "v",
+	}, logs.All()[0].ContextMap())
+
+	// FromContext does not attach the IDs
+	FromContext(ctx).Info("plain")
+	assert.Empty(t, logs.All()[1].Context)
+
+	// Without a logger in the context Ctx is a nop
+	assert.NotPanics(t, func() { Ctx(context.Background()).Info("dropped") })
+	assert.Nil(t, ContextFields(nil))
+}
+
+func TestRegisterContextExtractor(t *testing.T) {
+	contextExtractors.Lock()
+	names, m := slices.Clone(contextExtractors.names), contextExtractors.m
+	contextExtractors.m = map[string]ContextExtractor{}
+	for k, v := range m {
+		contextExtractors.m[k] = v
+	}
+	contextExtractors.Unlock()
+	t.Cleanup(func() {
+		contextExtractors.Lock()
+		contextExtractors.names, contextExtractors.m = names, m
+		contextExtractors.Unlock()
+	})
+
+	type tenantKey struct{}
+	RegisterContextExtractor("tenant", func(ctx context.Context) []Field {
+		if v, ok := ctx.Value(tenantKey{}).(string); ok {
+			return []Field{String("tenant", v)}
+		}
+		return nil
+	})
+	// Replaces the built-in extractor in place
+	RegisterContextExtractor("traceparent", func(context.Context) []Field {
+		return []Field{String("trace_id", "from-tracer")}
+	})
+	RegisterContextExtractor("", func(context.Context) []Field { return []Field{String("x", "y")} })
+	RegisterContextExtractor("nil", nil)
+
+	ctx := context.WithValue(WithRequestID(context.Background(), "req-1"), tenantKey{}, "acme")
+	assert.Equal(t, []Field{
+		String("trace_id", "from-tracer"),
+		String("request_id", "req-1"),
+		String("tenant", "acme"),
+	}, ContextFields(ctx))
+}
+
+func TestAddFields(t *testing.T) {
+	core, logs := observer.New(zapcore.InfoLevel)
+	base := WithContext(context.Background(), ProvideAdapter(zap.New(core)))
+
+	ctx := AddFields(base, String("user_id", "u1"), String("tenant", "acme"))
+	ctx = AddFields(ctx, String("user_id", "u2"), Int("n", 1), Int("n", 2))
+	assert.Equal(t, base, AddFields(base))
+
+	FromContext(ctx).Info("downstream")
+	require.Equal(t, 1, logs.Len())
+	assert.Equal(t, []Field{String("tenant", "acme"), String("user_id", "u2"), Int("n", 2)}, logs.All()[0].Context)
+
+	// Ctx attaches the fields once, after the extracted IDs
+	Ctx(WithRequestID(ctx, "req-1")).Info("with ids")
+	assert.Equal(t, []Field{String("request_id", "req-1"), String("tenant", "acme"), String("user_id", "u2"), Int("n", 2)}, logs.All()[1].Context)
+
+	// The parent context is unchanged
+	FromContext(base).Info("parent")
+	assert.Empty(t, logs.All()[2].Context)
+}
+
+func TestContextFields_RoundTrip(t *testing.T) {
+	core, logs := observer.New(zapcore.InfoLevel)
+	ctx := WithContext(context.Background(), ProvideAdapter(zap.New(core)))
+	ctx = WithRequestID(AddFields(ctx, String("user_id", "u1")), "req-1")
+
+	// A handler stores a derived logger back into the context
+	ctx = WithContext(ctx, Ctx(ctx).With(String("handler", "orders")))
+	ctx = WithContext(ctx, FromContext(ctx).Named("db"))
+	ctx = AddFields(ctx, Int("attempt", 2))
+
+	Ctx(ctx).Info("ctx")
+	FromContext(ctx).Info("from context")
+	want := []Field{String("request_id", "req-1"), String("user_id", "u1"), String("handler", "orders"), Int("attempt", 2)}
+	require.Equal(t, 2, logs.Len())
+	assert.Equal(t, want, logs.All()[0].Context)
+	assert.Equal(t, want, logs.All()[1].Context)
+}
+
+func TestContextFields_RoundTripReplaces(t *testing.T) {
+	core, logs := observer.New(zapcore.InfoLevel)
+	ctx := WithContext(context.Background(), ProvideAdapter(zap.New(core)))
+	ctx = WithRequestID(AddFields(ctx, String("user_id", "1")), "req-1")
+	ctx = WithContext(ctx, Ctx(ctx).With(String("handler", "orders")).Named("db"))
+
+	ctx = WithRequestID(AddFields(ctx, String("user_id", "2")), "req-2")
+	FromContext(ctx).Info("from context")
+	Ctx(ctx).Info("ctx")
+
+	require.Equal(t, 2, logs.Len())
+	assert.Equal(t, []Field{String("request_id", "req-1"), String("user_id", "2"), String("handler", "orders")}, logs.All()[0].Context)
+	assert.Equal(t, []Field{String("request_id", "req-2"), String("user_id", "2"), String("handler", "orders")}, logs.All()[1].Context)
+	assert.Equal(t, "db", logs.All()[1].LoggerName)
+}
+
+func TestContextFields_RoundTripUncomparable(t *testing.T) {
+	core, logs := observer.New(zapcore.InfoLevel)
+	ctx := WithContext(context.Background(), ProvideAdapter(zap.New(core)))
+	ctx = AddFields(ctx, zap.Stringer("ip", net.ParseIP("10.0.0.1")))
+	ctx = WithContext(ctx, FromContext(ctx))
+
+	require.NotPanics(t, func() { FromContext(ctx).Info("request") })
+	require.Equal(t, 1, logs.Len())
+	assert.Equal(t, map[string]any{"ip": "10.0.0.1"}, logs.All()[0].ContextMap())
+}
+
+func TestAddFields_Concurrent(t *testing.T) {
+	parent := AddFields(context.Background(), String("user_id", "u1"))
+
+	var wg sync.WaitGroup
+	results := make([][]Field, 8)
+	for i := range results {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ctx := AddFields(parent, Int("worker", i))
+			results[i] = ContextFields(ctx)
+		}()
+	}
+	wg.Wait()
+
+	for i, fields := range results {
+		assert.Equal(t, []Field{String("user_id", "u1"), Int("worker", i)}, fields)
+	}
+	assert.Equal(t, []Field{String("user_id", "u1")}, ContextFields(parent))
+}
@@ -0,0 +1,193 @@
+package logx
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// LevelController changes the level of the running logger without a
+// redeploy. logx.Module provides it to Fx; every change is written to the log
+// as an audit entry, regardless of the current level.
+//
+// Example:
+//
+//	fx.Invoke(func(levels *logx.LevelController, mux *http.ServeMux) {
+//	    mux.Handle("/debug/log/level", levels.Handler())
+//	})
+type LevelController struct {
+	level zap.AtomicLevel
+	// initial is the configured level, restored by Reset.
+	initial zapcore.Level
+
+	mu       sync.Mutex
+	logger   *zap.Logger
+	revert   *time.Timer
+	revertAt time.Time
+	// gen identifies the latest change, so a timer that fires after a newer
+	// change does not revert it.
+	gen uint64
+}
+
+// NewLevelController creates a LevelController at the configured level.
+// Unknown level names fall back to info.
+func NewLevelController(c LoggerConfig) *LevelController {
+	level := zapcore.InfoLevel
+	_ = level.Set(c.Level)
+	return &LevelController{level: zap.NewAtomicLevelAt(level), initial: level}
+}
+
+// AtomicLevel returns the level shared with the logger's cores.
+func (c *LevelController) AtomicLevel() zap.AtomicLevel { return c.level }
+
+// Level returns the current level.
+func (c *LevelController) Level() zapcore.Level { return c.level.Level() }
+
+// SetLevel changes the level and cancels a pending auto-revert. source
+// describes who made the change (e.g. "http", "signal") for the audit entry.
+func (c *LevelController) SetLevel(level zapcore.Level, source string, fields ...Field) {
+	c.SetLevelFor(level, 0, source, fields...)
+}
+
+// SetLevelFor changes the level and, when ttl is positive, reverts to the
+// previous level once ttl has passed.
+func (c *LevelController) SetLevelFor(level zapcore.Level, ttl time.Duration, source string, fields ...Field) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	previous := c.level.Level()
+	c.stopRevert()
+	c.gen++
+	c.level.SetLevel(level)
+
+	fields = append(fields, zap.String("source", source))
+	if ttl > 0 {
+		gen := c.gen
+		c.revertAt = time.Now().Add(ttl)
+		c.revert = time.AfterFunc(ttl, func() { c.expire(previous, gen) })
+		fields = append(fields, zap.Duration("ttl", ttl))
+	}
+	c.audit(previous, level, fields)
+}
+
+// Reset restores the configured level.
+func (c *LevelController) Reset(source string, fields ...Field) {
+	c.SetLevel(c.initial, source, fields...)
+}
+
+func (c *LevelController) expire(previous zapcore.Level, gen uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if gen != c.gen {
+		// Superseded by a later change
+		return
+	}
+	c.revert, c.revertAt = nil, time.Time{}
+
+	current := c.level.Level()
+	c.level.SetLevel(previous)
+	c.audit(current, previous, []Field{zap.String("source", "ttl")})
+}
+
+func (c *LevelController) stopRevert() {
+	if c.revert != nil {
+		c.revert.Stop()
+		c.revert, c.revertAt = nil, time.Time{}
+	}
+}
+
+// attach sets the logger that receives audit entries.
+func (c *LevelController) attach(l *zap.Logger) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.logger = l
+}
+
+// audit writes straight to the core so the entry is kept even when the new
+// level would filter it.
+func (c *LevelController) audit(from, to zapcore.Level, fields []Field) {
+	if c.logger == nil {
+		return
+	}
+	fields = append(fields, zap.Bool("audit", true), zap.Stringer("from", from), zap.Stringer("to", to))
+	_ = c.logger.Core().Write(zapcore.Entry{
+		Level:      zapcore.InfoLevel,
+		Time:       time.Now(),
+		LoggerName: c.logger.Name(),
+		Message:    "log level changed",
+	}, fields)
+}
+
+// levelState is the body of level handler responses.
+type levelState struct {
+	Level    string     `json:"level"`
+	Default  string     `json:"default"`
+	RevertAt *time.Time `json:"revert_at,omitempty"`
+}
+
+// levelRequest is the body of a PUT to the level handler.
+type levelRequest struct {
+	Level string `json:"level"`
+	TTL   string `json:"ttl"`
+}
+
+// Handler serves the level over HTTP. GET returns the current level; PUT
+// sets it from a JSON body {"level": "debug", "ttl": "10m"} or the level and
+// ttl query parameters. With a ttl, the previous level is restored after it.
+//
+// The handler changes what the service logs; mount it on an admin listener
+// only.
+func (c *LevelController) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet, http.MethodHead:
+		case http.MethodPut:
+			req := levelRequest{Level: r.URL.Query().Get("level"), TTL: r.URL.Query().Get("ttl")}
+			if req.Level == "" {
+				if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+					http.Error(w, fmt.Sprintf("invalid request body: %v", err), http.StatusBadRequest)
+					return
+				}
+			}
+
+			var level zapcore.Level
+			if err := level.Set(req.Level); err != nil || req.Level == "" {
+				http.Error(w, fmt.Sprintf("invalid level %q", req.Level), http.StatusBadRequest)
+				return
+			}
+			var ttl time.Duration
+			if req.TTL != "" {
+				var err error
+				if ttl, err = time.ParseDuration(req.TTL); err != nil || ttl < 0 {
+					http.Error(w, fmt.Sprintf("invalid ttl %q", req.TTL), http.StatusBadRequest)
+					return
+				}
+			}
+			c.SetLevelFor(level, ttl, "http", zap.String("remote_addr", r.RemoteAddr))
+		default:
+			w.Header().Set("Allow", "GET, HEAD, PUT")
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(c.state())
+	})
+}
+
+func (c *LevelController) state() levelState {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	s := levelState{Level: c.level.Level().String(), Default: c.initial.String()}
+	if !c.revertAt.IsZero() {
+		at := c.revertAt
+		s.RevertAt = &at
+	}
+	return s
+}
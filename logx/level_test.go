@@ -0,0 +1,158 @@
+package logx
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gostratum/core/configx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/fx"
+	"go.uber.org/fx/fxtest"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+// newObservedLevels returns a controller whose audit entries and level apply
+// to an observer core.
+func newObservedLevels(level string) (*LevelController, *observer.ObservedLogs) {
+	levels := NewLevelController(LoggerConfig{Level: level})
+	core, logs := observer.New(levels.AtomicLevel())
+	levels.attach(zap.New(core))
+	return levels, logs
+}
+
+func auditEntries(logs *observer.ObservedLogs) []observer.LoggedEntry {
+	return logs.FilterMessage("log level changed").FilterField(zap.Bool("audit", true)).All()
+}
+
+func TestNewLevelController(t *testing.T) {
+	assert.Equal(t, zapcore.WarnLevel, NewLevelController(LoggerConfig{Level: "warn"}).Level())
+	assert.Equal(t, zapcore.InfoLevel, NewLevelController(LoggerConfig{Level: "nope"}).Level())
+}
+
+func TestLevelController_SetLevelAudited(t *testing.T) {
+	levels, logs := newObservedLevels("info")
+
+	levels.SetLevel(zapcore.ErrorLevel, "test", zap.String("user", "ops"))
+	assert.Equal(t, zapcore.ErrorLevel, levels.Level())
+
+	// The audit entry is written although info is now filtered
+	entries := auditEntries(logs)
+	require.Len(t, entries, 1)
+	assert.Equal(t, map[string]any{
+		"audit":  true,
+		"from":   "info",
+		"to":     "error",
+		"source": "test",
+		"user":   "ops",
+	}, entries[0].ContextMap())
+
+	levels.Reset("test")
+	assert.Equal(t, zapcore.InfoLevel, levels.Level())
+	assert.Len(t, auditEntries(logs), 2)
+}
+
+func TestLevelController_TTLReverts(t *testing.T) {
+	levels, logs := newObservedLevels("warn")
+
+	levels.SetLevelFor(zapcore.DebugLevel, 20*time.Millisecond, "test")
+	assert.Equal(t, zapcore.DebugLevel, levels.Level())
+	assert.NotNil(t, levels.state().RevertAt)
+
+	assert.Eventually(t, func() bool { return levels.Level() == zapcore.WarnLevel }, time.Second, 5*time.Millisecond)
+	assert.Nil(t, levels.state().RevertAt)
+
+	entries := auditEntries(logs)
+	require.Len(t, entries, 2)
+	assert.Equal(t, 20*time.Millisecond, entries[0].ContextMap()["ttl"])
+	assert.Equal(t, "ttl", entries[1].ContextMap()["source"])
+	assert.Equal(t, "warn", entries[1].ContextMap()["to"])
+}
+
+func TestLevelController_LaterChangeCancelsRevert(t *testing.T) {
+	levels, _ := newObservedLevels("info")
+
+	levels.SetLevelFor(zapcore.DebugLevel, 10*time.Millisecond, "test")
+	levels.SetLevel(zapcore.ErrorLevel, "test")
+
+	time.Sleep(40 * time.Millisecond)
+	assert.Equal(t, zapcore.ErrorLevel, levels.Level())
+}
+
+func TestLevelController_Handler(t *testing.T) {
+	levels, logs := newObservedLevels("info")
+	h := levels.Handler()
+
+	do := func(method, target, body string) (*httptest.ResponseRecorder, levelState) {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(method, target, strings.NewReader(body))
+		req.RemoteAddr = "10.0.0.1:1234"
+		h.ServeHTTP(rec, req)
+		var state levelState
+		if rec.Code == http.StatusOK {
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &state))
+		}
+		return rec, state
+	}
+
+	rec, state := do(http.MethodGet, "/level", "")
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, levelState{Level: "info", Default: "info"}, state)
+
+	rec, state = do(http.MethodPut, "/level", `{"level": "debug", "ttl": "1h"}`)
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "debug", state.Level)
+	require.NotNil(t, state.RevertAt)
+	assert.WithinDuration(t, time.Now().Add(time.Hour), *state.RevertAt, time.Minute)
+
+	rec, state = do(http.MethodPut, "/level?level=warn", "")
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, levelState{Level: "warn", Default: "info"}, state)
+	assert.Equal(t, zapcore.WarnLevel, levels.Level())
+
+	entries := auditEntries(logs)
+	require.Len(t, entries, 2)
+	assert.Equal(t, "http", entries[1].ContextMap()["source"])
+	assert.Equal(t, "10.0.0.1:1234", entries[1].ContextMap()["remote_addr"])
+
+	for _, tt := range []struct {
+		method, target, body string
+		code                 int
+	}{
+		{http.MethodPut, "/level", `{"level": "loud"}`, http.StatusBadRequest},
+		{http.MethodPut, "/level", `{"level": ""}`, http.StatusBadRequest},
+		{http.MethodPut, "/level", `not json`, http.StatusBadRequest},
+		{http.MethodPut, "/level?level=debug&ttl=soon", "", http.StatusBadRequest},
+		{http.MethodPost, "/level", "", http.StatusMethodNotAllowed},
+	} {
+		rec, _ := do(tt.method, tt.target, tt.body)
+		assert.Equal(t, tt.code, rec.Code, "%s %s %s", tt.method, tt.target, tt.body)
+	}
+	assert.Equal(t, zapcore.WarnLevel, levels.Level())
+}
+
+func TestModule_ProvidesLevelController(t *testing.T) {
+	loader, err := configx.NewWithReader(strings.NewReader("core:\n  logger:\n    level: warn\n    dump_config: false\n"))
+	require.NoError(t, err)
+
+	var (
+		levels *LevelController
+		logger *zap.Logger
+	)
+	app := fxtest.New(t,
+		fx.Supply(fx.Annotate(loader, fx.As(new(configx.Loader)))),
+		Module(),
+		fx.Populate(&levels, &logger),
+	)
+	defer app.RequireStart().RequireStop()
+
+	assert.False(t, logger.Core().Enabled(zapcore.InfoLevel))
+	levels.SetLevel(zapcore.DebugLevel, "test")
+	assert.True(t, logger.Core().Enabled(zapcore.DebugLevel))
+}
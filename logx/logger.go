@@ -2,6 +2,7 @@ package logx
 
 import (
 	"context"
+	"errors"
 	"runtime"
 
 	"github.com/gostratum/core/configx"
@@ -24,6 +25,31 @@ type LoggerConfig struct {
 	// Optional sampling in prod
 	SamplingInitial    int `mapstructure:"sampling_initial" default:"100"`
 	SamplingThereafter int `mapstructure:"sampling_thereafter" default:"100"`
+	// true to log the redacted effective configuration once on start
+	DumpConfig bool `mapstructure:"dump_config" default:"false"`
+	// true to switch to debug on SIGUSR1 and back on SIGUSR2 (unix only).
+	// Off by default: the default action of both signals terminates the
+	// process, and other libraries in the binary may already handle them.
+	LevelSignals bool `mapstructure:"level_signals" default:"false"`
+	// Minimum levels per logger name (see Logger.Named), e.g.
+	// {"kafka": "warn", "http.access": "debug"}. A rule applies to the named
+	// logger and every logger below it; the longest matching name wins.
+	Levels map[string]any `mapstructure:"levels"`
+	// Destinations, e.g. ["stdout", {"path": "/var/log/app.log",
+	// "max_size": "100MB"}]; see OutputConfig. Empty logs to stderr.
+	Outputs []OutputConfig `mapstructure:"outputs" validate:"dive"`
+	// Several destinations, each with its own outputs, encoding, level and
+	// sampling; see SinkConfig. When set, Outputs is ignored.
+	Sinks []SinkConfig `mapstructure:"sinks" validate:"dive"`
+	// Masks secrets such as tokens and DSN passwords in messages and string
+	// fields; see ScrubConfig
+	Scrub ScrubConfig `mapstructure:"scrub"`
+	// Secret key matchers, allowlist and mask strategy for redaction; see
+	// RedactConfig
+	Redact RedactConfig `mapstructure:"redact"`
+	// Make the provided *slog.Logger slog.Default() while the app runs, so
+	// slog and standard log calls share the logger's pipeline
+	SlogDefault bool `mapstructure:"slog_default" default:"false"`
 }
 
 // Prefix enables configx.Bind
@@ -35,13 +61,16 @@ func NewLoggerConfig(loader configx.Loader) (LoggerConfig, error) {
 }
 
 func NewLogger(lc fx.Lifecycle, c LoggerConfig) (*zap.Logger, error) {
-	level := zapcore.InfoLevel
-	_ = level.Set(c.Level)
+	return newLogger(lc, c, NewLevelController(c))
+}
 
+// newLogger builds the logger with the level of levels, so changes made
+// through the controller apply to it.
+func newLogger(lc fx.Lifecycle, c LoggerConfig, levels *LevelController) (*zap.Logger, error) {
 	var cfg zap.Config
 	if c.Env == "dev" {
 		cfg = zap.NewDevelopmentConfig()
-		cfg.Level = zap.NewAtomicLevelAt(level)
+		cfg.Level = levels.AtomicLevel()
 		cfg.Encoding = ifEmpty(c.Encoding, "console")
 		// NewDevelopmentConfig already sets a human-friendly time encoder; only
 		// set a custom layout if a non-empty encoding is requested that
@@ -51,7 +80,7 @@ func NewLogger(lc fx.Lifecycle, c LoggerConfig) (*zap.Logger, error) {
 		}
 	} else {
 		cfg = zap.NewProductionConfig()
-		cfg.Level = zap.NewAtomicLevelAt(level)
+		cfg.Level = levels.AtomicLevel()
 		cfg.Encoding = ifEmpty(c.Encoding, "json")
 		cfg.Sampling = &zap.SamplingConfig{
 			Initial:    max(1, c.SamplingInitial),
@@ -67,33 +96,124 @@ func NewLogger(lc fx.Lifecycle, c LoggerConfig) (*zap.Logger, error) {
 	cfg.DisableCaller = !c.Caller
 	cfg.DisableStacktrace = !c.Stacktrace
 
-	// write to stdout by default
-	if len(cfg.OutputPaths) == 0 {
-		cfg.OutputPaths = []string{"stdout"}
-		cfg.ErrorOutputPaths = []string{"stderr"}
+	named, err := parseLevels(c.Levels)
+	if err != nil {
+		return nil, err
+	}
+	redactor, err := newRedactor(c.Redact)
+	if err != nil {
+		return nil, err
+	}
+	setRedactor(redactor)
+	scrub, err := newScrubber(c.Scrub)
+	if err != nil {
+		return nil, err
+	}
+	resolved, err := sinks(c, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		cores   []zapcore.Core
+		paths   []string
+		closers []func() error
+	)
+	closeOutputs := func() error {
+		var errs []error
+		for _, closeFn := range closers {
+			errs = append(errs, closeFn())
+		}
+		return errors.Join(errs...)
+	}
+	for _, s := range resolved {
+		out, closeFn, sinkPaths, err := openSink(s.outputs, cfg.OutputPaths)
+		if err != nil {
+			_ = closeOutputs()
+			return nil, err
+		}
+		closers = append(closers, closeFn)
+		paths = append(paths, sinkPaths...)
+
+		core, err := s.core(cfg.EncoderConfig, out, named, scrub)
+		if err != nil {
+			_ = closeOutputs()
+			return nil, err
+		}
+		cores = append(cores, core)
 	}
 
-	logger, err := cfg.Build()
+	logger, closeErrOut, err := buildLogger(cfg, zapcore.NewTee(cores...))
 	if err != nil {
+		_ = closeOutputs()
 		return nil, err
 	}
+	closers = append(closers, closeErrOut)
 
 	// Optionally set as global for libraries that use zap.L()
 	zap.ReplaceGlobals(logger)
+	levels.attach(logger)
 
 	lc.Append(fx.Hook{
 		OnStop: func(ctx context.Context) error {
 			// Avoid noisy sync error on Windows console
-			if runtime.GOOS == "windows" && isStdStream(cfg.OutputPaths) {
-				return nil
+			if runtime.GOOS != "windows" || !isStdStream(paths) {
+				_ = logger.Sync()
 			}
-			_ = logger.Sync()
-			return nil
+			return closeOutputs()
 		},
 	})
 	return logger, nil
 }
 
+// openSink opens outputs, or the zap paths when there are none. It returns
+// the writer, a func closing it and the output paths.
+func openSink(outputs []OutputConfig, defaults []string) (zapcore.WriteSyncer, func() error, []string, error) {
+	if len(outputs) == 0 {
+		out, closeFn, err := zap.Open(defaults...)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		return out, func() error { closeFn(); return nil }, defaults, nil
+	}
+
+	set, err := openOutputs(outputs)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	paths := make([]string, len(outputs))
+	for i, o := range outputs {
+		paths[i] = o.Path
+	}
+	return set, set.Close, paths, nil
+}
+
+// buildLogger applies the options cfg.Build would to core; the sinks'
+// cores handle encoding, levels and sampling. It returns a func closing the
+// error output.
+func buildLogger(cfg zap.Config, core zapcore.Core) (*zap.Logger, func() error, error) {
+	errOut, closeFn, err := zap.Open(cfg.ErrorOutputPaths...)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	opts := []zap.Option{zap.ErrorOutput(errOut)}
+	if cfg.Development {
+		opts = append(opts, zap.Development())
+	}
+	if !cfg.DisableCaller {
+		opts = append(opts, zap.AddCaller())
+	}
+	if !cfg.DisableStacktrace {
+		stackLevel := zap.ErrorLevel
+		if cfg.Development {
+			stackLevel = zap.WarnLevel
+		}
+		opts = append(opts, zap.AddStacktrace(stackLevel))
+	}
+	return zap.New(core, opts...), func() error { closeFn(); return nil }, nil
+}
+
 func ifEmpty(s, d string) string {
 	if s == "" {
 		return d
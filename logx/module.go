@@ -1,6 +1,7 @@
 package logx
 
 import (
+	"github.com/gostratum/core/configx"
 	"go.uber.org/fx"
 	"go.uber.org/fx/fxevent"
 	"go.uber.org/zap"
@@ -10,11 +11,14 @@ import (
 func Module() fx.Option {
 	return fx.Module(
 		"logx",
+		configx.Provide[LoggerConfig](),
 		fx.Provide(
-			NewLoggerConfig,
-			NewLogger,
+			NewLevelController,
+			newLogger,
 			ProvideAdapter,
+			NewSlogLogger,
 		),
+		fx.Invoke(routeLoaderWarnings, logEffectiveConfig, warnPrefixConflicts, watchLevelSignals, setSlogDefault),
 		fx.WithLogger(FxEventLogger),
 	)
 }
@@ -0,0 +1,106 @@
+package logx
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// levelsCore applies per-logger-name minimum levels. The wrapped core must
+// accept every level; entries of loggers without a rule use the default
+// level.
+type levelsCore struct {
+	zapcore.Core
+	def zapcore.LevelEnabler
+	// names are sorted longest first, so the first match is the longest.
+	names  []string
+	levels map[string]zapcore.Level
+}
+
+// newLevelsCore wraps core with the given per-name levels. Names are
+// dot-separated and match a logger and all loggers named below it.
+func newLevelsCore(core zapcore.Core, def zapcore.LevelEnabler, levels map[string]zapcore.Level) zapcore.Core {
+	names := make([]string, 0, len(levels))
+	for name := range levels {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool { return len(names[i]) > len(names[j]) })
+	return &levelsCore{Core: core, def: def, names: names, levels: levels}
+}
+
+// Enabled reports whether any logger may log at lvl.
+func (c *levelsCore) Enabled(lvl zapcore.Level) bool {
+	if c.def.Enabled(lvl) {
+		return true
+	}
+	for _, min := range c.levels {
+		if lvl >= min {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *levelsCore) With(fields []zapcore.Field) zapcore.Core {
+	clone := *c
+	clone.Core = c.Core.With(fields)
+	return &clone
+}
+
+func (c *levelsCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if min, ok := c.levelFor(ent.LoggerName); ok {
+		if ent.Level < min {
+			return ce
+		}
+	} else if !c.def.Enabled(ent.Level) {
+		return ce
+	}
+	return c.Core.Check(ent, ce)
+}
+
+// levelFor returns the level of the longest rule matching name.
+func (c *levelsCore) levelFor(name string) (zapcore.Level, bool) {
+	name = strings.ToLower(name)
+	for _, rule := range c.names {
+		if name == rule || strings.HasPrefix(name, rule+".") {
+			return c.levels[rule], true
+		}
+	}
+	return 0, false
+}
+
+// parseLevels flattens the levels config, where viper turns dotted names
+// into nested maps, into lower-case dot-separated names.
+func parseLevels(raw map[string]any) (map[string]zapcore.Level, error) {
+	out := map[string]zapcore.Level{}
+	var walk func(prefix string, m map[string]any) error
+	walk = func(prefix string, m map[string]any) error {
+		for k, v := range m {
+			name := strings.ToLower(k)
+			if prefix != "" {
+				name = prefix + "." + name
+			}
+			switch val := v.(type) {
+			case map[string]any:
+				if err := walk(name, val); err != nil {
+					return err
+				}
+			case string:
+				var lvl zapcore.Level
+				if err := lvl.Set(val); err != nil || val == "" {
+					return fmt.Errorf("invalid level %q for logger %q", val, name)
+				}
+				out[name] = lvl
+			default:
+				return fmt.Errorf("invalid level %v for logger %q", v, name)
+			}
+		}
+		return nil
+	}
+	if err := walk("", raw); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
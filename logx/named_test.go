@@ -0,0 +1,140 @@
+package logx
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/gostratum/core/configx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/fx"
+	"go.uber.org/fx/fxtest"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestParseLevels(t *testing.T) {
+	levels, err := parseLevels(map[string]any{
+		"Kafka": "warn",
+		"http": map[string]any{
+			"access": "debug",
+			"client": map[string]any{"retry": "error"},
+		},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]zapcore.Level{
+		"kafka":             zapcore.WarnLevel,
+		"http.access":       zapcore.DebugLevel,
+		"http.client.retry": zapcore.ErrorLevel,
+	}, levels)
+
+	_, err = parseLevels(map[string]any{"kafka": "loud"})
+	assert.ErrorContains(t, err, `invalid level "loud" for logger "kafka"`)
+	_, err = parseLevels(map[string]any{"kafka": 3})
+	assert.ErrorContains(t, err, `invalid level 3 for logger "kafka"`)
+}
+
+func TestLevelsCore(t *testing.T) {
+	def := zap.NewAtomicLevelAt(zapcore.InfoLevel)
+	inner, logs := observer.New(zapcore.DebugLevel)
+	logger := zap.New(newLevelsCore(inner, def, map[string]zapcore.Level{
+		"kafka":       zapcore.WarnLevel,
+		"http.access": zapcore.DebugLevel,
+	}))
+	adapter := ProvideAdapter(logger)
+
+	tests := []struct {
+		name    string
+		logger  Logger
+		level   zapcore.Level
+		written bool
+	}{
+		{"root info", adapter, zapcore.InfoLevel, true},
+		{"root debug", adapter, zapcore.DebugLevel, false},
+		{"rule raises level", adapter.Named("kafka"), zapcore.InfoLevel, false},
+		{"rule keeps warn", adapter.Named("kafka"), zapcore.WarnLevel, true},
+		{"child inherits rule", adapter.Named("kafka").Named("consumer"), zapcore.InfoLevel, false},
+		{"prefix only on dot boundary", adapter.Named("kafkaesque"), zapcore.InfoLevel, true},
+		{"rule lowers level", adapter.Named("http").Named("access"), zapcore.DebugLevel, true},
+		{"parent of rule uses default", adapter.Named("http"), zapcore.DebugLevel, false},
+		{"names are case-insensitive", adapter.Named("HTTP.Access"), zapcore.DebugLevel, true},
+		{"With keeps rules", adapter.Named("kafka").With(String("k", "v")), zapcore.InfoLevel, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			before := logs.Len()
+			switch tt.level {
+			case zapcore.DebugLevel:
+				tt.logger.Debug("msg")
+			case zapcore.InfoLevel:
+				tt.logger.Info("msg")
+			case zapcore.WarnLevel:
+				tt.logger.Warn("msg")
+			}
+			assert.Equal(t, tt.written, logs.Len() > before)
+		})
+	}
+
+	// Loggers without a rule follow the default level as it changes
+	def.SetLevel(zapcore.DebugLevel)
+	adapter.Debug("now enabled")
+	adapter.Named("kafka").Info("still filtered")
+	assert.Equal(t, 1, logs.FilterMessage("now enabled").Len())
+	assert.Zero(t, logs.FilterMessage("still filtered").Len())
+}
+
+func TestLevelsCore_Enabled(t *testing.T) {
+	inner, _ := observer.New(zapcore.DebugLevel)
+	core := newLevelsCore(inner, zapcore.WarnLevel, map[string]zapcore.Level{"http.access": zapcore.DebugLevel})
+
+	// Some logger may log debug, so the core must not drop it up front
+	assert.True(t, core.Enabled(zapcore.DebugLevel))
+
+	core = newLevelsCore(inner, zapcore.WarnLevel, map[string]zapcore.Level{"kafka": zapcore.ErrorLevel})
+	assert.False(t, core.Enabled(zapcore.InfoLevel))
+	assert.True(t, core.Enabled(zapcore.WarnLevel))
+}
+
+func TestModule_NamedLevels(t *testing.T) {
+	loader, err := configx.NewWithReader(strings.NewReader(`
+core:
+  logger:
+    level: info
+    dump_config: false
+    levels:
+      kafka: warn
+      http.access: debug
+`))
+	require.NoError(t, err)
+
+	var (
+		logger *zap.Logger
+		levels *LevelController
+	)
+	app := fxtest.New(t,
+		fx.Supply(fx.Annotate(loader, fx.As(new(configx.Loader)))),
+		Module(),
+		fx.Populate(&logger, &levels),
+	)
+	defer app.RequireStart().RequireStop()
+
+	assert.Nil(t, logger.Named("kafka").Check(zapcore.InfoLevel, "msg"))
+	assert.NotNil(t, logger.Named("http").Named("access").Check(zapcore.DebugLevel, "msg"))
+	assert.Nil(t, logger.Check(zapcore.DebugLevel, "msg"))
+
+	// The LevelController still sets the default level
+	levels.SetLevel(zapcore.DebugLevel, "test")
+	assert.NotNil(t, logger.Check(zapcore.DebugLevel, "msg"))
+	assert.Nil(t, logger.Named("kafka").Check(zapcore.InfoLevel, "msg"))
+}
+
+func TestNewLogger_InvalidNamedLevel(t *testing.T) {
+	app := fx.New(
+		fx.NopLogger,
+		fx.Supply(LoggerConfig{Env: "prod", Levels: map[string]any{"kafka": "loud"}}),
+		fx.Provide(NewLogger),
+		fx.Invoke(func(*zap.Logger) {}),
+	)
+	assert.ErrorContains(t, app.Err(), `invalid level "loud" for logger "kafka"`)
+}
@@ -0,0 +1,92 @@
+package logx
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/gostratum/core/configx"
+	"go.uber.org/zap/zapcore"
+)
+
+// OutputConfig is a log destination: "stdout", "stderr" or a file path.
+// Files are appended to and, when MaxSize or Daily is set, rotated. Rotated
+// files are named after the file with a timestamp, e.g.
+// app-2025-01-02T15-04-05.000.log, and optionally gzipped.
+//
+// A plain string decodes as an output with only Path set, so both of these
+// work:
+//
+//	core:
+//	  logger:
+//	    outputs:
+//	      - stdout
+//	      - path: /var/log/app.log
+//	        max_size: 100MB
+//	        daily: true
+//	        max_backups: 7
+//	        compress: true
+type OutputConfig struct {
+	Path string `mapstructure:"path" validate:"required"`
+	// Rotate once the file would grow beyond this size; 0 disables
+	MaxSize configx.ByteSize `mapstructure:"max_size"`
+	// Rotate when the date changes
+	Daily bool `mapstructure:"daily"`
+	// Rotated files to keep; 0 keeps all
+	MaxBackups int `mapstructure:"max_backups"`
+	// Remove rotated files older than this; 0 keeps them
+	MaxAge time.Duration `mapstructure:"max_age"`
+	// Gzip rotated files
+	Compress bool `mapstructure:"compress"`
+}
+
+// UnmarshalText decodes an output given as a plain path.
+func (o *OutputConfig) UnmarshalText(text []byte) error {
+	*o = OutputConfig{Path: string(text)}
+	return nil
+}
+
+// outputSet is the combined destination of the configured outputs.
+type outputSet struct {
+	zapcore.WriteSyncer
+	closers []io.Closer
+}
+
+// openOutputs opens every output. Files already opened are closed again
+// when one fails.
+func openOutputs(outputs []OutputConfig) (*outputSet, error) {
+	set := &outputSet{}
+	syncers := make([]zapcore.WriteSyncer, 0, len(outputs))
+	for _, o := range outputs {
+		switch o.Path {
+		case "":
+			_ = set.Close()
+			return nil, errors.New("log output path is empty")
+		case "stdout":
+			syncers = append(syncers, zapcore.Lock(os.Stdout))
+		case "stderr":
+			syncers = append(syncers, zapcore.Lock(os.Stderr))
+		default:
+			f, err := newRotatingFile(o)
+			if err != nil {
+				_ = set.Close()
+				return nil, fmt.Errorf("log output %s: %w", o.Path, err)
+			}
+			syncers = append(syncers, f)
+			set.closers = append(set.closers, f)
+		}
+	}
+	set.WriteSyncer = zapcore.NewMultiWriteSyncer(syncers...)
+	return set, nil
+}
+
+// Close closes the file outputs.
+func (s *outputSet) Close() error {
+	var errs []error
+	for _, c := range s.closers {
+		errs = append(errs, c.Close())
+	}
+	return errors.Join(errs...)
+}
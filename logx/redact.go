@@ -0,0 +1,417 @@
+package logx
+
+import (
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// redacted replaces secret values in log output.
+const redacted = "[redacted]"
+
+// maxRedactDepth bounds recursion through self-referencing values.
+const maxRedactDepth = 32
+
+// redactedField logs val with its secrets redacted (see Any). The plan of
+// each type is computed once; values of types that cannot contain secrets
+// are logged as by zap.Any.
+func redactedField(key string, val any) zap.Field {
+	v := reflect.ValueOf(val)
+	p := planFor(v.Type())
+	if p.isClean() {
+		return zap.Any(key, val)
+	}
+	switch e := redactValue(v, p, 0).(type) {
+	case zapcore.ObjectMarshaler:
+		return zap.Object(key, e)
+	case zapcore.ArrayMarshaler:
+		return zap.Array(key, e)
+	default:
+		return zap.Any(key, e)
+	}
+}
+
+type planKind int
+
+const (
+	// Encoded as is, e.g. numbers, strings, time.Time
+	planLeaf planKind = iota
+	planPointer
+	// Dynamic: the plan of the contained value is looked up when logging
+	planInterface
+	planStruct
+	planSlice
+	planMap
+)
+
+// typePlan describes how to log values of a type.
+type typePlan struct {
+	kind planKind
+	// sanitizable values are replaced by their Sanitize result
+	sanitizable bool
+	// addrSanitizable values are sanitizable through a pointer receiver
+	addrSanitizable bool
+	// elem is the plan of pointer, slice and map elements
+	elem   *typePlan
+	fields []fieldPlan
+
+	cleanOnce sync.Once
+	clean     bool
+}
+
+type fieldPlan struct {
+	index     int
+	name      string
+	redact    bool
+	omitEmpty bool
+	plan      *typePlan
+	// inline fields belong to an embedded struct without a json name
+	inline bool
+}
+
+var (
+	plans            sync.Map // reflect.Type -> *typePlan
+	sanitizableType  = reflect.TypeOf((*Sanitizable)(nil)).Elem()
+	jsonMarshalerTyp = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshalerTyp = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// planFor returns the cached plan of t, building plans for t and the types
+// it refers to on first use.
+func planFor(t reflect.Type) *typePlan {
+	if p, ok := plans.Load(t); ok {
+		return p.(*typePlan)
+	}
+	built := map[reflect.Type]*typePlan{}
+	p := buildPlan(t, built)
+	for bt, bp := range built {
+		plans.LoadOrStore(bt, bp)
+	}
+	return p
+}
+
+func buildPlan(t reflect.Type, built map[reflect.Type]*typePlan) *typePlan {
+	if p, ok := plans.Load(t); ok {
+		return p.(*typePlan)
+	}
+	if p, ok := built[t]; ok {
+		// Recursive type
+		return p
+	}
+	p := &typePlan{}
+	built[t] = p
+
+	p.sanitizable = t.Implements(sanitizableType)
+	p.addrSanitizable = !p.sanitizable && t.Kind() != reflect.Interface && reflect.PointerTo(t).Implements(sanitizableType)
+
+	switch {
+	case t.Kind() == reflect.Interface:
+		p.kind = planInterface
+	case t.Kind() == reflect.Pointer:
+		p.kind = planPointer
+		p.elem = buildPlan(t.Elem(), built)
+	case t.Implements(jsonMarshalerTyp) || t.Implements(textMarshalerTyp) ||
+		reflect.PointerTo(t).Implements(jsonMarshalerTyp) || reflect.PointerTo(t).Implements(textMarshalerTyp):
+		// Types that choose their own encoding, such as time.Time
+		p.kind = planLeaf
+	case t.Kind() == reflect.Struct:
+		p.kind = planStruct
+		p.fields = structFields(t, built)
+	case (t.Kind() == reflect.Slice || t.Kind() == reflect.Array) && t.Elem().Kind() != reflect.Uint8:
+		p.kind = planSlice
+		p.elem = buildPlan(t.Elem(), built)
+	case t.Kind() == reflect.Map:
+		p.kind = planMap
+		p.elem = buildPlan(t.Elem(), built)
+	default:
+		p.kind = planLeaf
+	}
+	return p
+}
+
+func structFields(t reflect.Type, built map[reflect.Type]*typePlan) []fieldPlan {
+	r := activeRedactor.Load()
+	var fields []fieldPlan
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("log")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "-" && opts == "" {
+			continue
+		}
+
+		if f.Anonymous && name == "" {
+			// Promoted like encoding/json does, which ignores pointers to
+			// unexported structs
+			ft := f.Type
+			if ft.Kind() == reflect.Pointer && f.IsExported() {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				fields = append(fields, fieldPlan{index: i, plan: buildPlan(f.Type, built), inline: true})
+				continue
+			}
+		}
+		if !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		fields = append(fields, fieldPlan{
+			index:     i,
+			name:      name,
+			redact:    r.isSecretField(f, name),
+			omitEmpty: strings.Contains(","+opts+",", ",omitempty,"),
+			plan:      buildPlan(f.Type, built),
+		})
+	}
+	return fields
+}
+
+// isClean reports whether values of the type can never hold anything to
+// redact, so they can be logged without inspection.
+func (p *typePlan) isClean() bool {
+	p.cleanOnce.Do(func() { p.clean = !p.mayRedact(map[*typePlan]bool{}) })
+	return p.clean
+}
+
+func (p *typePlan) mayRedact(seen map[*typePlan]bool) bool {
+	if seen[p] {
+		return false
+	}
+	seen[p] = true
+	// Interfaces may hold anything and map keys are only known when logging
+	if p.sanitizable || p.addrSanitizable || p.kind == planInterface || p.kind == planMap {
+		return true
+	}
+	if p.elem != nil && p.elem.mayRedact(seen) {
+		return true
+	}
+	for _, f := range p.fields {
+		if f.redact || f.plan.mayRedact(seen) {
+			return true
+		}
+	}
+	return false
+}
+
+// redactValue returns what to encode for v: a zapcore.ObjectMarshaler or
+// ArrayMarshaler that redacts while encoding, or a value to encode as is.
+func redactValue(v reflect.Value, p *typePlan, depth int) any {
+	return redactValueOf(v, p, depth, true)
+}
+
+// redactValueOf skips Sanitize when sanitize is false, for results of
+// Sanitize that are Sanitizable themselves.
+func redactValueOf(v reflect.Value, p *typePlan, depth int, sanitize bool) any {
+	if !v.IsValid() {
+		return nil
+	}
+	if depth > maxRedactDepth {
+		return "[max depth]"
+	}
+	if p.isClean() && v.CanInterface() {
+		return v.Interface()
+	}
+	if sanitize && v.CanInterface() {
+		if s, ok := sanitized(v, p); ok {
+			if s == nil {
+				return nil
+			}
+			sv := reflect.ValueOf(s)
+			return redactValueOf(sv, planFor(sv.Type()), depth+1, false)
+		}
+	}
+
+	switch p.kind {
+	case planPointer:
+		if v.IsNil() {
+			return nil
+		}
+		return redactValueOf(v.Elem(), p.elem, depth+1, sanitize)
+	case planInterface:
+		if v.IsNil() {
+			return nil
+		}
+		return redactValueOf(v.Elem(), planFor(v.Elem().Type()), depth+1, sanitize)
+	case planStruct:
+		return structMarshaler{v: v, p: p, depth: depth}
+	case planSlice:
+		if v.Kind() == reflect.Slice && v.IsNil() {
+			return nil
+		}
+		return sliceMarshaler{v: v, p: p, depth: depth}
+	case planMap:
+		if v.IsNil() {
+			return nil
+		}
+		return mapMarshaler{v: v, p: p, depth: depth}
+	default:
+		return leafValue(v)
+	}
+}
+
+// leafValue returns the value of v, which may have been reached through an
+// unexported embedded struct and so not allow Interface.
+func leafValue(v reflect.Value) any {
+	if v.CanInterface() {
+		return v.Interface()
+	}
+	switch v.Kind() {
+	case reflect.Bool:
+		return v.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int()
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return v.Uint()
+	case reflect.Float32, reflect.Float64:
+		return v.Float()
+	case reflect.String:
+		return v.String()
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// sanitized returns the Sanitize result of Sanitizable values.
+func sanitized(v reflect.Value, p *typePlan) (any, bool) {
+	switch {
+	case p.sanitizable:
+		if (v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface) && v.IsNil() {
+			return nil, true
+		}
+		return v.Interface().(Sanitizable).Sanitize(), true
+	case p.addrSanitizable:
+		ptr := reflect.New(v.Type())
+		ptr.Elem().Set(v)
+		return ptr.Interface().(Sanitizable).Sanitize(), true
+	}
+	return nil, false
+}
+
+type structMarshaler struct {
+	v     reflect.Value
+	p     *typePlan
+	depth int
+}
+
+func (m structMarshaler) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	return m.addFields(enc, m.v, m.p)
+}
+
+func (m structMarshaler) addFields(enc zapcore.ObjectEncoder, v reflect.Value, p *typePlan) error {
+	for _, f := range p.fields {
+		fv := v.Field(f.index)
+		if f.inline {
+			if fv.Kind() == reflect.Pointer {
+				if fv.IsNil() {
+					continue
+				}
+				fv = fv.Elem()
+			}
+			if err := m.addFields(enc, fv, planFor(fv.Type())); err != nil {
+				return err
+			}
+			continue
+		}
+		if f.omitEmpty && isEmptyValue(fv) {
+			continue
+		}
+		if f.redact {
+			enc.AddString(f.name, activeRedactor.Load().maskReflect(fv))
+			continue
+		}
+		if err := addEncodable(enc, f.name, redactValue(fv, f.plan, m.depth+1)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type sliceMarshaler struct {
+	v     reflect.Value
+	p     *typePlan
+	depth int
+}
+
+func (m sliceMarshaler) MarshalLogArray(enc zapcore.ArrayEncoder) error {
+	for i := 0; i < m.v.Len(); i++ {
+		if err := appendEncodable(enc, redactValue(m.v.Index(i), m.p.elem, m.depth+1)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type mapMarshaler struct {
+	v     reflect.Value
+	p     *typePlan
+	depth int
+}
+
+func (m mapMarshaler) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	r := activeRedactor.Load()
+	iter := m.v.MapRange()
+	for iter.Next() {
+		k := iter.Key()
+		key := fmt.Sprint(k.Interface())
+		if k.Kind() == reflect.String {
+			key = k.String()
+		}
+		if r.isSecret(key) {
+			enc.AddString(key, r.maskReflect(iter.Value()))
+			continue
+		}
+		if err := addEncodable(enc, key, redactValue(iter.Value(), m.p.elem, m.depth+1)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func addEncodable(enc zapcore.ObjectEncoder, key string, e any) error {
+	switch e := e.(type) {
+	case zapcore.ObjectMarshaler:
+		return enc.AddObject(key, e)
+	case zapcore.ArrayMarshaler:
+		return enc.AddArray(key, e)
+	default:
+		return enc.AddReflected(key, e)
+	}
+}
+
+func appendEncodable(enc zapcore.ArrayEncoder, e any) error {
+	switch e := e.(type) {
+	case zapcore.ObjectMarshaler:
+		return enc.AppendObject(e)
+	case zapcore.ArrayMarshaler:
+		return enc.AppendArray(e)
+	default:
+		return enc.AppendReflected(e)
+	}
+}
+
+// isEmptyValue matches encoding/json's omitempty.
+func isEmptyValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64,
+		reflect.Interface, reflect.Pointer:
+		return v.IsZero()
+	}
+	return false
+}
@@ -0,0 +1,203 @@
+package logx
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// encodeField returns the JSON encoding of f's value.
+func encodeField(t *testing.T, f zap.Field) any {
+	t.Helper()
+	enc := zapcore.NewJSONEncoder(zapcore.EncoderConfig{})
+	buf, err := enc.EncodeEntry(zapcore.Entry{}, []zap.Field{f})
+	require.NoError(t, err)
+	var out map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &out))
+	return out[f.Key]
+}
+
+type redactCredentials struct {
+	User     string
+	Password string
+}
+
+type redactAccount struct {
+	ID       int               `json:"id"`
+	Email    string            `json:"email,omitempty"`
+	SSN      string            `json:"ssn" log:"redact"`
+	Internal string            `log:"-"`
+	Skipped  string            `json:"-"`
+	Creds    redactCredentials `json:"creds"`
+	Backup   *redactCredentials
+	Labels   map[string]string
+	Others   []redactCredentials
+	Extra    any
+	Created  time.Time
+	private  string
+}
+
+func TestAny_DeepRedaction(t *testing.T) {
+	created := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	acct := redactAccount{
+		ID:       7,
+		SSN:      "123-45-6789",
+		Internal: "hidden",
+		Skipped:  "hidden",
+		Creds:    redactCredentials{User: "alice", Password: "p1"},
+		Backup:   &redactCredentials{User: "bob", Password: "p2"},
+		Labels:   map[string]string{"team": "core", "api_token": "t1"},
+		Others:   []redactCredentials{{User: "carol", Password: "p3"}},
+		Extra:    map[string]any{"secret": "s1", "list": []any{map[string]any{"pem": "x", "ok": 1}}},
+		Created:  created,
+		private:  "hidden",
+	}
+
+	assert.Equal(t, map[string]any{
+		"id":      float64(7),
+		"ssn":     "[redacted]",
+		"creds":   map[string]any{"User": "alice", "Password": "[redacted]"},
+		"Backup":  map[string]any{"User": "bob", "Password": "[redacted]"},
+		"Labels":  map[string]any{"team": "core", "api_token": "[redacted]"},
+		"Others":  []any{map[string]any{"User": "carol", "Password": "[redacted]"}},
+		"Extra":   map[string]any{"secret": "[redacted]", "list": []any{map[string]any{"pem": "[redacted]", "ok": float64(1)}}},
+		"Created": "2025-01-02T03:04:05Z",
+	}, encodeField(t, Any("account", &acct)))
+
+	// Nil nested values
+	assert.Equal(t, map[string]any{
+		"id": float64(0), "ssn": "[redacted]",
+		"creds":  map[string]any{"User": "", "Password": "[redacted]"},
+		"Backup": nil, "Labels": nil, "Others": nil, "Extra": nil,
+		"Created": "0001-01-01T00:00:00Z",
+	}, encodeField(t, Any("account", redactAccount{})))
+	assert.Nil(t, encodeField(t, Any("account", (*redactAccount)(nil))))
+
+	// Top-level slices and maps
+	assert.Equal(t, []any{map[string]any{"User": "dan", "Password": "[redacted]"}},
+		encodeField(t, Any("creds", []redactCredentials{{User: "dan", Password: "p"}})))
+	assert.Equal(t, map[string]any{"token": "[redacted]", "n": float64(1)},
+		encodeField(t, Any("m", map[string]int{"token": 5, "n": 1})))
+}
+
+type redactNoSecrets struct {
+	Host  string
+	Port  int
+	Tags  []string
+	Inner struct{ A, B int }
+}
+
+func TestAny_CleanTypesUnchanged(t *testing.T) {
+	v := redactNoSecrets{Host: "localhost", Port: 80}
+	f := Any("v", v)
+	// Logged as by zap.Any, without the redacting marshaler
+	assert.Equal(t, zap.Any("v", v), f)
+	assert.True(t, planFor(reflect.TypeOf(v)).isClean())
+
+	assert.Equal(t, zap.Any("n", 3), Any("n", 3))
+	assert.Equal(t, zap.Any("b", []byte("x")), Any("b", []byte("x")))
+	assert.Equal(t, zap.Any("t", time.Second), Any("t", time.Second))
+}
+
+type redactEmbedded struct {
+	Token string
+	Count int
+}
+
+type redactOuter struct {
+	redactEmbedded
+	Name string
+}
+
+type redactExported struct {
+	*RedactInner
+	Name string
+}
+
+type RedactInner struct {
+	APIKey string
+	Region string
+}
+
+func TestAny_EmbeddedStructs(t *testing.T) {
+	// Fields of unexported embedded structs are promoted as by encoding/json
+	assert.False(t, planFor(reflect.TypeOf(redactOuter{})).isClean())
+	assert.Equal(t, map[string]any{"Token": "[redacted]", "Name": "n", "Count": float64(2)},
+		encodeField(t, Any("v", redactOuter{redactEmbedded{"t", 2}, "n"})))
+
+	assert.Equal(t, map[string]any{"Name": "n", "APIKey": "[redacted]", "Region": "eu"},
+		encodeField(t, Any("v", redactExported{&RedactInner{APIKey: "k", Region: "eu"}, "n"})))
+	assert.Equal(t, map[string]any{"Name": "n"}, encodeField(t, Any("v", redactExported{Name: "n"})))
+}
+
+type redactNode struct {
+	Name   string
+	Secret string
+	Next   *redactNode
+}
+
+type redactA struct {
+	B     *redactB
+	Token string
+}
+
+type redactB struct {
+	A *redactA
+}
+
+func TestAny_RecursiveTypes(t *testing.T) {
+	n := &redactNode{Name: "a", Secret: "s", Next: &redactNode{Name: "b", Secret: "s"}}
+	assert.Equal(t, map[string]any{
+		"Name": "a", "Secret": "[redacted]",
+		"Next": map[string]any{"Name": "b", "Secret": "[redacted]", "Next": nil},
+	}, encodeField(t, Any("n", n)))
+
+	// B reaches a secret only through A
+	assert.False(t, planFor(reflect.TypeOf(redactB{})).isClean())
+	assert.Equal(t, map[string]any{"A": map[string]any{"B": nil, "Token": "[redacted]"}},
+		encodeField(t, Any("b", redactB{A: &redactA{Token: "t"}})))
+
+	// Cycles stop at the depth limit
+	cyclic := &redactNode{Name: "loop"}
+	cyclic.Next = cyclic
+	assert.NotPanics(t, func() { encodeField(t, Any("n", cyclic)) })
+}
+
+type redactWithSanitizable struct {
+	Name string
+	DB   valueConfigWithSecret
+	Cfg  *testConfigWithSecrets
+}
+
+func TestAny_NestedSanitizable(t *testing.T) {
+	v := redactWithSanitizable{
+		Name: "svc",
+		DB:   valueConfigWithSecret{Public: "p", Secret: "s"},
+		Cfg:  &testConfigWithSecrets{Host: "h", Port: 1, Password: "pw", APIKey: "k"},
+	}
+	assert.Equal(t, map[string]any{
+		"Name": "svc",
+		"DB":   map[string]any{"Public": "p", "Secret": "[redacted]"},
+		"Cfg":  map[string]any{"Host": "h", "Port": float64(1), "Password": "[redacted]", "APIKey": "[redacted]"},
+	}, encodeField(t, Any("v", v)))
+}
+
+func BenchmarkAny_DeepRedaction(b *testing.B) {
+	logger := zap.New(zapcore.NewCore(zapcore.NewJSONEncoder(zapcore.EncoderConfig{}), zapcore.AddSync(discard{}), zapcore.InfoLevel))
+	acct := &redactAccount{ID: 1, Creds: redactCredentials{User: "u", Password: "p"}, Labels: map[string]string{"a": "b"}}
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		logger.Info("Test", Any("account", acct))
+	}
+}
+
+type discard struct{}
+
+func (discard) Write(p []byte) (int, error) { return len(p), nil }
@@ -0,0 +1,210 @@
+package logx
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"slices"
+	"strings"
+	"sync/atomic"
+)
+
+// RedactConfig selects which keys are secret and how their values are
+// masked, for Any, SanitizeMap, Sensitive, the effective config dump and
+// the scrubbing patterns.
+//
+//	core:
+//	  logger:
+//	    redact:
+//	      keys: [ssn]
+//	      suffixes: [_token, _secret]
+//	      patterns: ['^x-.*-auth$']
+//	      allow: [partition_key]
+//	      mask: hash
+//	      hash_key: ${LOG_HASH_KEY}
+type RedactConfig struct {
+	// true to stop treating keys containing password, secret, token, ...
+	// or ending in a key segment (key, ssh_key, tls.key, ...) as secret,
+	// leaving only the matchers below
+	DisableBuiltin bool `mapstructure:"disable_builtin"`
+	// Secret keys, matched case-insensitively
+	Keys []string `mapstructure:"keys"`
+	// Secret key suffixes, matched case-insensitively
+	Suffixes []string `mapstructure:"suffixes"`
+	// Secret key regular expressions
+	Patterns []*regexp.Regexp `mapstructure:"patterns"`
+	// Keys that are never secret, matched case-insensitively
+	Allow []string `mapstructure:"allow"`
+	// "full" replaces values by [redacted], "last" keeps the last KeepLast
+	// characters and "hash" logs a stable HMAC-SHA256 of the value, so equal
+	// values can be correlated across log lines
+	Mask     string `mapstructure:"mask" default:"full" validate:"oneof=full last hash"`
+	KeepLast int    `mapstructure:"keep_last" default:"4" validate:"gte=0"`
+	// HMAC key for mask "hash"; required with it
+	HashKey string `mapstructure:"hash_key"`
+}
+
+// Sanitize hides the hash key from the effective config dump.
+func (c RedactConfig) Sanitize() any {
+	if c.HashKey != "" {
+		c.HashKey = redacted
+	}
+	return c
+}
+
+// redactor decides which keys are secret and masks their values.
+type redactor struct {
+	builtin  bool
+	keys     []string
+	suffixes []string
+	patterns []*regexp.Regexp
+	allow    []string
+
+	mask     string
+	keepLast int
+	hashKey  []byte
+}
+
+// defaultRedactor matches the built-in secret keys and fully masks values.
+var defaultRedactor = &redactor{builtin: true, mask: "full"}
+
+var activeRedactor atomic.Pointer[redactor]
+
+func init() { activeRedactor.Store(defaultRedactor) }
+
+// newRedactor validates c and builds its redactor.
+func newRedactor(c RedactConfig) (*redactor, error) {
+	r := &redactor{
+		builtin:  !c.DisableBuiltin,
+		keys:     lowerAll(c.Keys),
+		suffixes: lowerAll(c.Suffixes),
+		allow:    lowerAll(c.Allow),
+		mask:     ifEmpty(c.Mask, "full"),
+		keepLast: c.KeepLast,
+	}
+	for _, re := range c.Patterns {
+		if re != nil {
+			r.patterns = append(r.patterns, re)
+		}
+	}
+	switch r.mask {
+	case "full", "last":
+	case "hash":
+		if c.HashKey == "" {
+			return nil, errors.New("redact mask hash requires hash_key")
+		}
+		r.hashKey = []byte(c.HashKey)
+	default:
+		return nil, fmt.Errorf("unknown redact mask %q", c.Mask)
+	}
+	return r, nil
+}
+
+// setRedactor makes r the redactor of the package. Cached redaction plans
+// depend on it and are dropped.
+func setRedactor(r *redactor) {
+	activeRedactor.Store(r)
+	plans.Range(func(k, _ any) bool {
+		plans.Delete(k)
+		return true
+	})
+}
+
+func lowerAll(in []string) []string {
+	out := make([]string, len(in))
+	for i, s := range in {
+		out[i] = strings.ToLower(s)
+	}
+	return out
+}
+
+// allowed reports whether key is on the allowlist.
+func (r *redactor) allowed(key string) bool {
+	return slices.Contains(r.allow, strings.ToLower(key))
+}
+
+// isSecret reports whether values under key must be masked.
+func (r *redactor) isSecret(key string) bool {
+	lk := strings.ToLower(key)
+	if slices.Contains(r.allow, lk) {
+		return false
+	}
+	if r.builtin && isSecretKey(key) || slices.Contains(r.keys, lk) {
+		return true
+	}
+	for _, s := range r.suffixes {
+		if strings.HasSuffix(lk, s) {
+			return true
+		}
+	}
+	for _, re := range r.patterns {
+		if re.MatchString(key) {
+			return true
+		}
+	}
+	return false
+}
+
+// isSecretField reports whether the value of struct field f, encoded under
+// name, must be masked: it is tagged log:"redact", or its Go or encoded name
+// is secret.
+func (r *redactor) isSecretField(f reflect.StructField, name string) bool {
+	if f.Tag.Get("log") == "redact" {
+		return true
+	}
+	return (r.isSecret(f.Name) || r.isSecret(name)) && !r.allowed(f.Name) && !r.allowed(name)
+}
+
+// maskString masks a secret string.
+func (r *redactor) maskString(s string) string {
+	switch r.mask {
+	case "last":
+		runes := []rune(s)
+		// Short values would be revealed entirely
+		if r.keepLast == 0 || len(runes) <= 2*r.keepLast {
+			return redacted
+		}
+		return "****" + string(runes[len(runes)-r.keepLast:])
+	case "hash":
+		h := hmac.New(sha256.New, r.hashKey)
+		h.Write([]byte(s))
+		return "hmac:" + hex.EncodeToString(h.Sum(nil)[:8])
+	default:
+		return redacted
+	}
+}
+
+// maskValue masks a secret of any type. Values are formatted only for the
+// strategies that need them.
+func (r *redactor) maskValue(v any) string {
+	return r.maskReflect(reflect.ValueOf(v))
+}
+
+func (r *redactor) maskReflect(rv reflect.Value) string {
+	if r.mask == "full" {
+		return redacted
+	}
+	for rv.Kind() == reflect.Pointer || rv.Kind() == reflect.Interface {
+		if rv.IsNil() {
+			return redacted
+		}
+		rv = rv.Elem()
+	}
+	if !rv.IsValid() {
+		return redacted
+	}
+	switch {
+	case rv.Kind() == reflect.String:
+		return r.maskString(rv.String())
+	case rv.Kind() == reflect.Slice && rv.Type().Elem().Kind() == reflect.Uint8:
+		return r.maskString(string(rv.Bytes()))
+	case rv.CanInterface():
+		return r.maskString(fmt.Sprint(rv.Interface()))
+	default:
+		return r.maskString(fmt.Sprint(leafValue(rv)))
+	}
+}
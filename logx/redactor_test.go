@@ -0,0 +1,169 @@
+package logx
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/gostratum/core/configx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/fx"
+	"go.uber.org/fx/fxtest"
+	"go.uber.org/zap"
+)
+
+// useRedactor installs the redactor of c for the test.
+func useRedactor(t *testing.T, c RedactConfig) {
+	t.Helper()
+	r, err := newRedactor(c)
+	require.NoError(t, err)
+	setRedactor(r)
+	t.Cleanup(func() { setRedactor(defaultRedactor) })
+}
+
+func TestRedactor_Matchers(t *testing.T) {
+	r, err := newRedactor(RedactConfig{
+		Keys:     []string{"SSN"},
+		Suffixes: []string{"_sig"},
+		Patterns: []*regexp.Regexp{regexp.MustCompile(`(?i)^x-.*-auth$`)},
+		Allow:    []string{"Partition_Key"},
+	})
+	require.NoError(t, err)
+
+	for key, want := range map[string]bool{
+		"password":       true,
+		"api_token":      true,
+		"ssn":            true,
+		"request_sig":    true,
+		"X-Tenant-Auth":  true,
+		"partition_key":  false,
+		"cache_key":      false,
+		"monkey":         false,
+		"keyspace":       false,
+		"CacheKey":       false,
+		"key":            true,
+		"ssh_key":        true,
+		"tls.key":        true,
+		"client-key":     true,
+		"jwt.key":        true,
+		"LicenseKey":     true,
+		"APIKey":         true,
+		"api_key":        true,
+		"X-Api-Key":      true,
+		"secretKey":      true,
+		"aws.access_key": true,
+		"name":           false,
+		"ssn_hint":       false,
+	} {
+		assert.Equal(t, want, r.isSecret(key), key)
+	}
+
+	r, err = newRedactor(RedactConfig{DisableBuiltin: true, Keys: []string{"ssn"}})
+	require.NoError(t, err)
+	assert.False(t, r.isSecret("password"))
+	assert.True(t, r.isSecret("SSN"))
+}
+
+func TestRedactor_Masks(t *testing.T) {
+	r, err := newRedactor(RedactConfig{})
+	require.NoError(t, err)
+	assert.Equal(t, "[redacted]", r.maskValue("4111111111111111"))
+
+	r, err = newRedactor(RedactConfig{Mask: "last", KeepLast: 4})
+	require.NoError(t, err)
+	assert.Equal(t, "****1111", r.maskValue("4111111111111111"))
+	assert.Equal(t, "****7890", r.maskValue(1234567890))
+	s := "token-abcd"
+	assert.Equal(t, "****abcd", r.maskValue(&s))
+	assert.Equal(t, "****abcd", r.maskValue([]byte("token-abcd")))
+	// Too short to keep anything
+	assert.Equal(t, "[redacted]", r.maskValue("abcd1234"))
+	assert.Equal(t, "[redacted]", r.maskValue((*string)(nil)))
+
+	r, err = newRedactor(RedactConfig{Mask: "hash", HashKey: "k1"})
+	require.NoError(t, err)
+	h := r.maskValue("s3cr3t")
+	assert.Regexp(t, `^hmac:[0-9a-f]{16}$`, h)
+	assert.Equal(t, h, r.maskValue("s3cr3t"))
+	assert.NotEqual(t, h, r.maskValue("other"))
+	other, err := newRedactor(RedactConfig{Mask: "hash", HashKey: "k2"})
+	require.NoError(t, err)
+	assert.NotEqual(t, h, other.maskValue("s3cr3t"))
+
+	_, err = newRedactor(RedactConfig{Mask: "hash"})
+	assert.ErrorContains(t, err, "requires hash_key")
+	_, err = newRedactor(RedactConfig{Mask: "blur"})
+	assert.ErrorContains(t, err, `unknown redact mask "blur"`)
+}
+
+type redactorUser struct {
+	Name     string
+	CacheKey string `json:"cache_key"`
+	Password string
+	SSN      string
+}
+
+func TestRedactor_AppliesEverywhere(t *testing.T) {
+	useRedactor(t, RedactConfig{Keys: []string{"ssn"}, Allow: []string{"cache_key"}, Mask: "last", KeepLast: 2})
+
+	u := redactorUser{Name: "n", CacheKey: "users:1", Password: "hunter22", SSN: "123-45-6789"}
+	assert.Equal(t, map[string]any{
+		"Name": "n", "cache_key": "users:1", "Password": "****22", "SSN": "****89",
+	}, encodeField(t, Any("u", u)))
+
+	assert.Equal(t, map[string]any{"cache_key": "users:1", "ssn": "****89", "api_token": "****yz"},
+		SanitizeMap(map[string]any{"cache_key": "users:1", "ssn": "123-45-6789", "api_token": "abcdwxyz"}))
+	assert.Equal(t, zap.String("card", "****11"), Sensitive("card", "4111111111111111"))
+
+	s, err := newScrubber(ScrubConfig{Enabled: true, Builtins: []string{"credit_card"}})
+	require.NoError(t, err)
+	assert.Equal(t, "paid with ****11", s.scrub("paid with 4111111111111111"))
+}
+
+func TestRedactConfig_Sanitize(t *testing.T) {
+	assert.Equal(t, RedactConfig{Mask: "hash", HashKey: "[redacted]"}, RedactConfig{Mask: "hash", HashKey: "k"}.Sanitize())
+	assert.Equal(t, RedactConfig{}, RedactConfig{}.Sanitize())
+}
+
+func TestModule_Redact(t *testing.T) {
+	t.Cleanup(func() { setRedactor(defaultRedactor) })
+	path := filepath.Join(t.TempDir(), "app.log")
+	loader, err := configx.NewWithReader(strings.NewReader(fmt.Sprintf(`
+core:
+  logger:
+    env: prod
+    dump_config: false
+    outputs: [%s]
+    redact:
+      allow: [cache_key]
+      mask: hash
+      hash_key: test-key
+`, path)))
+	require.NoError(t, err)
+
+	var (
+		logger *zap.Logger
+		c      LoggerConfig
+	)
+	app := fxtest.New(t,
+		fx.Supply(fx.Annotate(loader, fx.As(new(configx.Loader)))),
+		Module(),
+		fx.Populate(&logger, &c),
+	)
+	app.RequireStart()
+	logger.Info("user", Any("u", redactorUser{CacheKey: "users:1", Password: "hunter22"}))
+	app.RequireStop()
+
+	assert.Equal(t, "hash", c.Redact.Mask)
+	assert.Equal(t, 4, c.Redact.KeepLast)
+
+	b, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Contains(t, string(b), `"cache_key":"users:1"`)
+	assert.NotContains(t, string(b), "hunter22")
+	assert.Regexp(t, `"Password":"hmac:[0-9a-f]{16}"`, string(b))
+}
@@ -0,0 +1,294 @@
+package logx
+
+import (
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// backupTimeFormat is the timestamp in rotated file names, e.g.
+// app-2025-01-02T15-04-05.000.log. Rotations within the same millisecond
+// add a counter: app-2025-01-02T15-04-05.000-1.log.
+const backupTimeFormat = "2006-01-02T15-04-05.000"
+
+// rotatingFile is a zapcore.WriteSyncer writing to a file that is rotated by
+// size and/or daily. Rotated files are renamed with a timestamp, optionally
+// gzipped, and pruned by count and age in the background.
+type rotatingFile struct {
+	cfg OutputConfig
+	now func() time.Time
+
+	mu     sync.Mutex
+	file   *os.File
+	closed bool
+	size   int64
+	// day is the date the current file was opened, for daily rotation.
+	day string
+
+	// mill wakes the goroutine that compresses and prunes backups.
+	mill     chan struct{}
+	millDone chan struct{}
+}
+
+func newRotatingFile(cfg OutputConfig) (*rotatingFile, error) {
+	return newRotatingFileAt(cfg, time.Now)
+}
+
+func newRotatingFileAt(cfg OutputConfig, now func() time.Time) (*rotatingFile, error) {
+	f := &rotatingFile{
+		cfg:      cfg,
+		now:      now,
+		mill:     make(chan struct{}, 1),
+		millDone: make(chan struct{}),
+	}
+	if err := os.MkdirAll(filepath.Dir(cfg.Path), 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create log directory: %w", err)
+	}
+	if err := f.open(); err != nil {
+		return nil, err
+	}
+	go f.runMill()
+	// Prune backups left by earlier runs
+	f.mill <- struct{}{}
+	return f, nil
+}
+
+func (f *rotatingFile) open() error {
+	file, err := os.OpenFile(f.cfg.Path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to open log file: %w", err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return fmt.Errorf("failed to open log file: %w", err)
+	}
+	f.file, f.size = file, info.Size()
+
+	// An existing file continues the day it was last written
+	f.day = info.ModTime().Format(time.DateOnly)
+	if info.Size() == 0 {
+		f.day = f.now().Format(time.DateOnly)
+	}
+	return nil
+}
+
+func (f *rotatingFile) Write(p []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.closed {
+		return 0, os.ErrClosed
+	}
+	if f.file == nil {
+		// A previous rotation could not reopen the file
+		if err := f.open(); err != nil {
+			return 0, err
+		}
+	}
+
+	var rotateErr error
+	if f.size > 0 && (f.cfg.Daily && f.now().Format(time.DateOnly) != f.day ||
+		f.cfg.MaxSize > 0 && f.size+int64(len(p)) > int64(f.cfg.MaxSize)) {
+		// On failure the entry still goes to the current file
+		if rotateErr = f.rotate(); f.file == nil {
+			return 0, rotateErr
+		}
+	}
+
+	n, err := f.file.Write(p)
+	f.size += int64(n)
+	return n, errors.Join(rotateErr, err)
+}
+
+// rotate renames the current file to a backup and opens a new one. When the
+// rename fails, the current file is reopened and rotation is retried on the
+// next write.
+func (f *rotatingFile) rotate() error {
+	err := f.file.Close()
+	f.file = nil
+	if err != nil {
+		return errors.Join(fmt.Errorf("failed to rotate log file: %w", err), f.open())
+	}
+	if err := os.Rename(f.cfg.Path, f.backupName(f.now())); err != nil {
+		return errors.Join(fmt.Errorf("failed to rotate log file: %w", err), f.open())
+	}
+	if err := f.open(); err != nil {
+		return err
+	}
+
+	select {
+	case f.mill <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// backupName returns a backup path for time t that is not taken by another
+// backup, compressed or not.
+func (f *rotatingFile) backupName(t time.Time) string {
+	dir, base := filepath.Split(f.cfg.Path)
+	ext := filepath.Ext(base)
+	stamp := strings.TrimSuffix(base, ext) + "-" + t.Format(backupTimeFormat)
+	name := filepath.Join(dir, stamp+ext)
+	for n := 1; exists(name) || exists(name+".gz"); n++ {
+		name = filepath.Join(dir, stamp+"-"+strconv.Itoa(n)+ext)
+	}
+	return name
+}
+
+func exists(path string) bool {
+	_, err := os.Lstat(path)
+	return err == nil
+}
+
+func (f *rotatingFile) Sync() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.file == nil {
+		return nil
+	}
+	return f.file.Sync()
+}
+
+// Close closes the file and waits for pending backup compression.
+func (f *rotatingFile) Close() error {
+	f.mu.Lock()
+	if f.closed {
+		f.mu.Unlock()
+		return nil
+	}
+	f.closed = true
+	var err error
+	if f.file != nil {
+		err = f.file.Close()
+		f.file = nil
+	}
+	f.mu.Unlock()
+
+	close(f.mill)
+	<-f.millDone
+	return err
+}
+
+func (f *rotatingFile) runMill() {
+	defer close(f.millDone)
+	for range f.mill {
+		_ = f.millBackups()
+	}
+}
+
+type backup struct {
+	path string
+	t    time.Time
+	// n is the counter of backups rotated within the same millisecond.
+	n int
+}
+
+// millBackups compresses uncompressed backups and removes backups beyond
+// MaxBackups or older than MaxAge.
+func (f *rotatingFile) millBackups() error {
+	backups, err := f.backups()
+	if err != nil {
+		return err
+	}
+
+	var keep []backup
+	for i, b := range backups {
+		expired := f.cfg.MaxAge > 0 && f.now().Sub(b.t) > f.cfg.MaxAge
+		if f.cfg.MaxBackups > 0 && i >= f.cfg.MaxBackups || expired {
+			_ = os.Remove(b.path)
+			continue
+		}
+		keep = append(keep, b)
+	}
+
+	if f.cfg.Compress {
+		for _, b := range keep {
+			if !strings.HasSuffix(b.path, ".gz") {
+				_ = gzipFile(b.path)
+			}
+		}
+	}
+	return nil
+}
+
+// backups lists rotated files of the log, newest first.
+func (f *rotatingFile) backups() ([]backup, error) {
+	dir, base := filepath.Split(f.cfg.Path)
+	if dir == "" {
+		dir = "."
+	}
+	ext := filepath.Ext(base)
+	prefix := strings.TrimSuffix(base, ext) + "-"
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var out []backup
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		stamp := strings.TrimSuffix(strings.TrimSuffix(strings.TrimPrefix(name, prefix), ".gz"), ext)
+		n := 0
+		if len(stamp) > len(backupTimeFormat) {
+			counter, ok := strings.CutPrefix(stamp[len(backupTimeFormat):], "-")
+			if n, err = strconv.Atoi(counter); !ok || err != nil || n < 1 {
+				continue
+			}
+			stamp = stamp[:len(backupTimeFormat)]
+		}
+		t, err := time.ParseInLocation(backupTimeFormat, stamp, time.Local)
+		if err != nil {
+			continue
+		}
+		out = append(out, backup{path: filepath.Join(dir, name), t: t, n: n})
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if !out[i].t.Equal(out[j].t) {
+			return out[i].t.After(out[j].t)
+		}
+		return out[i].n > out[j].n
+	})
+	return out, nil
+}
+
+// gzipFile replaces path with path.gz.
+func gzipFile(path string) error {
+	src, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	dst, err := os.OpenFile(path+".gz", os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o644)
+	if err != nil {
+		return err
+	}
+	zw := gzip.NewWriter(dst)
+	if _, err := io.Copy(zw, src); err != nil {
+		dst.Close()
+		os.Remove(path + ".gz")
+		return err
+	}
+	if err := zw.Close(); err != nil {
+		dst.Close()
+		os.Remove(path + ".gz")
+		return err
+	}
+	if err := dst.Close(); err != nil {
+		return err
+	}
+	return os.Remove(path)
+}
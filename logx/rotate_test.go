@@ -0,0 +1,334 @@
+package logx
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gostratum/core/configx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/fx"
+	"go.uber.org/fx/fxtest"
+	"go.uber.org/zap"
+)
+
+// fakeClock is a settable clock for rotation tests.
+type fakeClock struct {
+	mu sync.Mutex
+	t  time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.t
+}
+
+func (c *fakeClock) Add(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.t = c.t.Add(d)
+}
+
+func dirNames(t *testing.T, dir string) []string {
+	t.Helper()
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	var names []string
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	sort.Strings(names)
+	return names
+}
+
+func TestRotatingFile_Size(t *testing.T) {
+	dir := t.TempDir()
+	clock := &fakeClock{t: time.Date(2025, 1, 2, 15, 4, 5, 0, time.Local)}
+	f, err := newRotatingFileAt(OutputConfig{Path: filepath.Join(dir, "app.log"), MaxSize: 10}, clock.Now)
+	require.NoError(t, err)
+
+	_, err = f.Write([]byte("12345678\n"))
+	require.NoError(t, err)
+	clock.Add(time.Second)
+	// Would exceed 10 bytes: rotates first
+	_, err = f.Write([]byte("abc\n"))
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	assert.Equal(t, []string{"app-2025-01-02T15-04-06.000.log", "app.log"}, dirNames(t, dir))
+	b, _ := os.ReadFile(filepath.Join(dir, "app-2025-01-02T15-04-06.000.log"))
+	assert.Equal(t, "12345678\n", string(b))
+	b, _ = os.ReadFile(filepath.Join(dir, "app.log"))
+	assert.Equal(t, "abc\n", string(b))
+}
+
+func TestRotatingFile_OversizedWrite(t *testing.T) {
+	dir := t.TempDir()
+	f, err := newRotatingFile(OutputConfig{Path: filepath.Join(dir, "app.log"), MaxSize: 4})
+	require.NoError(t, err)
+
+	// A single write larger than MaxSize goes to a fresh file as a whole
+	_, err = f.Write([]byte("0123456789"))
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+	assert.Equal(t, []string{"app.log"}, dirNames(t, dir))
+}
+
+func TestRotatingFile_RotateFails(t *testing.T) {
+	dir := t.TempDir()
+	clock := &fakeClock{t: time.Date(2025, 1, 2, 15, 4, 5, 0, time.Local)}
+	f, err := newRotatingFileAt(OutputConfig{Path: filepath.Join(dir, "app.log"), MaxSize: 10}, clock.Now)
+	require.NoError(t, err)
+
+	_, err = f.Write([]byte("12345678\n"))
+	require.NoError(t, err)
+	// Removing the file makes the rename fail; the entry goes to a new file
+	require.NoError(t, os.Remove(filepath.Join(dir, "app.log")))
+	n, err := f.Write([]byte("abc\n"))
+	assert.ErrorContains(t, err, "failed to rotate log file")
+	assert.Equal(t, 4, n)
+
+	// Rotation is retried on the next write and succeeds
+	clock.Add(time.Second)
+	_, err = f.Write([]byte("0123456789\n"))
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	assert.Equal(t, []string{"app-2025-01-02T15-04-06.000.log", "app.log"}, dirNames(t, dir))
+	b, _ := os.ReadFile(filepath.Join(dir, "app-2025-01-02T15-04-06.000.log"))
+	assert.Equal(t, "abc\n", string(b))
+	b, _ = os.ReadFile(filepath.Join(dir, "app.log"))
+	assert.Equal(t, "0123456789\n", string(b))
+}
+
+func TestRotatingFile_SameMillisecond(t *testing.T) {
+	dir := t.TempDir()
+	clock := &fakeClock{t: time.Date(2025, 1, 2, 15, 4, 5, 0, time.Local)}
+	// A compressed backup from an earlier run takes the first name
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "app-2025-01-02T15-04-05.000.log.gz"), []byte("x"), 0o644))
+	f, err := newRotatingFileAt(OutputConfig{Path: filepath.Join(dir, "app.log"), MaxSize: 1}, clock.Now)
+	require.NoError(t, err)
+
+	for _, line := range []string{"a\n", "b\n", "c\n"} {
+		_, err := f.Write([]byte(line))
+		require.NoError(t, err)
+	}
+	require.NoError(t, f.Close())
+
+	// No backup is replaced
+	assert.Equal(t, []string{
+		"app-2025-01-02T15-04-05.000-1.log",
+		"app-2025-01-02T15-04-05.000-2.log",
+		"app-2025-01-02T15-04-05.000.log.gz",
+		"app.log",
+	}, dirNames(t, dir))
+	b, _ := os.ReadFile(filepath.Join(dir, "app-2025-01-02T15-04-05.000-2.log"))
+	assert.Equal(t, "b\n", string(b))
+
+	backups, err := f.backups()
+	require.NoError(t, err)
+	require.Len(t, backups, 3)
+	// Later counters are newer
+	assert.Equal(t, filepath.Join(dir, "app-2025-01-02T15-04-05.000-2.log"), backups[0].path)
+	assert.Equal(t, filepath.Join(dir, "app-2025-01-02T15-04-05.000.log.gz"), backups[2].path)
+}
+
+func TestRotatingFile_CloseStopsMill(t *testing.T) {
+	dir := t.TempDir()
+	f, err := newRotatingFile(OutputConfig{Path: filepath.Join(dir, "app.log")})
+	require.NoError(t, err)
+
+	// Without a current file, Close still stops the backup goroutine
+	f.mu.Lock()
+	require.NoError(t, f.file.Close())
+	f.file = nil
+	f.mu.Unlock()
+
+	require.NoError(t, f.Close())
+	select {
+	case <-f.millDone:
+	default:
+		t.Fatal("backup goroutine still running after Close")
+	}
+	require.NoError(t, f.Close())
+
+	_, err = f.Write([]byte("x"))
+	assert.ErrorIs(t, err, os.ErrClosed)
+}
+
+func TestRotatingFile_Daily(t *testing.T) {
+	dir := t.TempDir()
+	clock := &fakeClock{t: time.Date(2025, 1, 2, 23, 59, 0, 0, time.Local)}
+	f, err := newRotatingFileAt(OutputConfig{Path: filepath.Join(dir, "app.log"), Daily: true}, clock.Now)
+	require.NoError(t, err)
+
+	_, err = f.Write([]byte("day one\n"))
+	require.NoError(t, err)
+	clock.Add(30 * time.Second)
+	_, err = f.Write([]byte("still day one\n"))
+	require.NoError(t, err)
+	clock.Add(time.Minute)
+	_, err = f.Write([]byte("day two\n"))
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	assert.Equal(t, []string{"app-2025-01-03T00-00-30.000.log", "app.log"}, dirNames(t, dir))
+	b, _ := os.ReadFile(filepath.Join(dir, "app.log"))
+	assert.Equal(t, "day two\n", string(b))
+}
+
+func TestRotatingFile_AppendsExisting(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nested", "app.log")
+	f, err := newRotatingFile(OutputConfig{Path: path})
+	require.NoError(t, err)
+	_, _ = f.Write([]byte("one\n"))
+	require.NoError(t, f.Close())
+
+	f, err = newRotatingFile(OutputConfig{Path: path})
+	require.NoError(t, err)
+	_, _ = f.Write([]byte("two\n"))
+	require.NoError(t, f.Sync())
+	require.NoError(t, f.Close())
+
+	b, _ := os.ReadFile(path)
+	assert.Equal(t, "one\ntwo\n", string(b))
+
+	_, err = f.Write([]byte("closed"))
+	assert.ErrorIs(t, err, os.ErrClosed)
+	assert.NoError(t, f.Close())
+}
+
+func TestRotatingFile_CompressAndPrune(t *testing.T) {
+	dir := t.TempDir()
+	clock := &fakeClock{t: time.Date(2025, 1, 2, 12, 0, 0, 0, time.Local)}
+	f, err := newRotatingFileAt(OutputConfig{
+		Path:       filepath.Join(dir, "app.log"),
+		MaxSize:    1,
+		MaxBackups: 2,
+		Compress:   true,
+	}, clock.Now)
+	require.NoError(t, err)
+
+	for i := range 5 {
+		clock.Add(time.Second)
+		_, err := f.Write([]byte(fmt.Sprintf("line %d\n", i)))
+		require.NoError(t, err)
+		// Let the mill catch up so every rotation is processed
+		require.Eventually(t, func() bool {
+			for _, n := range dirNames(t, dir) {
+				if strings.HasSuffix(n, "000.log") {
+					return false
+				}
+			}
+			return true
+		}, time.Second, 5*time.Millisecond)
+	}
+	require.NoError(t, f.Close())
+
+	assert.Equal(t, []string{
+		"app-2025-01-02T12-00-04.000.log.gz",
+		"app-2025-01-02T12-00-05.000.log.gz",
+		"app.log",
+	}, dirNames(t, dir))
+
+	gz, err := os.Open(filepath.Join(dir, "app-2025-01-02T12-00-05.000.log.gz"))
+	require.NoError(t, err)
+	defer gz.Close()
+	zr, err := gzip.NewReader(gz)
+	require.NoError(t, err)
+	b, err := io.ReadAll(zr)
+	require.NoError(t, err)
+	assert.Equal(t, "line 3\n", string(b))
+}
+
+func TestRotatingFile_MaxAge(t *testing.T) {
+	dir := t.TempDir()
+	clock := &fakeClock{t: time.Date(2025, 1, 2, 12, 0, 0, 0, time.Local)}
+	old := filepath.Join(dir, "app-2024-12-01T00-00-00.000.log.gz")
+	recent := filepath.Join(dir, "app-2025-01-01T00-00-00.000.log")
+	other := filepath.Join(dir, "app-notes.txt")
+	for _, p := range []string{old, recent, other} {
+		require.NoError(t, os.WriteFile(p, []byte("x"), 0o644))
+	}
+
+	// Backups left by earlier runs are pruned when the file is opened,
+	// before any rotation
+	f, err := newRotatingFileAt(OutputConfig{Path: filepath.Join(dir, "app.log"), MaxAge: 7 * 24 * time.Hour}, clock.Now)
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	assert.Equal(t, []string{"app-2025-01-01T00-00-00.000.log", "app-notes.txt", "app.log"}, dirNames(t, dir))
+}
+
+func TestOutputConfig_Decode(t *testing.T) {
+	loader, err := configx.NewWithReader(strings.NewReader(`
+core:
+  logger:
+    outputs:
+      - stdout
+      - path: /var/log/app.log
+        max_size: 10MiB
+        daily: true
+        max_backups: 3
+        max_age: 72h
+        compress: true
+`))
+	require.NoError(t, err)
+
+	var c LoggerConfig
+	require.NoError(t, loader.Bind(&c))
+	assert.Equal(t, []OutputConfig{
+		{Path: "stdout"},
+		{
+			Path:       "/var/log/app.log",
+			MaxSize:    10 << 20,
+			Daily:      true,
+			MaxBackups: 3,
+			MaxAge:     72 * time.Hour,
+			Compress:   true,
+		},
+	}, c.Outputs)
+}
+
+func TestOpenOutputs_EmptyPath(t *testing.T) {
+	_, err := openOutputs([]OutputConfig{{Path: "stdout"}, {}})
+	assert.ErrorContains(t, err, "log output path is empty")
+}
+
+func TestModule_FileOutput(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "logs", "app.log")
+	loader, err := configx.NewWithReader(strings.NewReader(fmt.Sprintf(`
+core:
+  logger:
+    env: prod
+    dump_config: false
+    outputs:
+      - path: %s
+        max_size: 1KB
+`, path)))
+	require.NoError(t, err)
+
+	var logger *zap.Logger
+	app := fxtest.New(t,
+		fx.Supply(fx.Annotate(loader, fx.As(new(configx.Loader)))),
+		Module(),
+		fx.Populate(&logger),
+	)
+	app.RequireStart()
+	logger.Info("to the file", zap.String("k", "v"))
+	app.RequireStop()
+
+	b, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Contains(t, string(b), `"msg":"to the file"`)
+	assert.Contains(t, string(b), `"k":"v"`)
+}
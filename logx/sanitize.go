@@ -8,20 +8,23 @@ import (
 )
 
 // Sensitive returns a zap.Field that represents a sensitive value. Use this when
-// you need to mark a value as secret at the call site.
-func Sensitive(key string, _ any) zap.Field {
-	return zap.String(key, "[redacted]")
+// you need to mark a value as secret at the call site. The value is masked as
+// configured by core.logger.redact.mask.
+func Sensitive(key string, val any) zap.Field {
+	return zap.String(key, activeRedactor.Load().maskValue(val))
 }
 
 // SanitizeMap returns a shallow copy of the input map where keys that look like
 // secrets are redacted. Keys are tested case-insensitively for substrings like
-// password, secret, token, key, api_key, apikey, private, pem, hmac.
+// password, secret, token, private, pem, hmac, for key and names ending in a
+// key segment such as ssh_key or tls.key (except cache_key), and for the
+// matchers and allowlist of core.logger.redact.
 func SanitizeMap(in map[string]any) map[string]any {
+	r := activeRedactor.Load()
 	out := make(map[string]any, len(in))
 	for k, v := range in {
-		lk := strings.ToLower(k)
-		if isSecretKey(lk) {
-			out[k] = "[redacted]"
+		if r.isSecret(k) {
+			out[k] = r.maskValue(v)
 			continue
 		}
 		// If value itself is a map[string]any, sanitize nested maps shallowly.
@@ -37,12 +40,49 @@ func SanitizeMap(in map[string]any) map[string]any {
 	return out
 }
 
-func isSecretKey(k string) bool {
-	secrets := []string{"password", "passwd", "secret", "token", "key", "api_key", "apikey", "private", "pem", "hmac"}
+func isSecretKey(key string) bool {
+	k := strings.ToLower(key)
+	secrets := []string{"password", "passwd", "secret", "token", "private", "pem", "hmac"}
 	for _, s := range secrets {
 		if strings.Contains(k, s) {
 			return true
 		}
 	}
+
+	// A key ending in a "key" segment (key, tls.key, ssh_key, LicenseKey) is
+	// secret; monkey and keyspace are not, and neither is a cache key
+	joined := keySeparators.Replace(k)
+	if joined == "cachekey" {
+		return false
+	}
+	if lastKeySegment(key) == "key" {
+		return true
+	}
+	for _, s := range secretKeySuffixes {
+		if strings.HasSuffix(joined, s) {
+			return true
+		}
+	}
 	return false
 }
+
+var keySeparators = strings.NewReplacer("_", "", "-", "", ".", "")
+
+// secretKeySuffixes are secret key names written without separators, such
+// as apikey, which have no "key" segment of their own.
+var secretKeySuffixes = []string{"apikey", "accesskey", "authkey", "encryptionkey", "masterkey", "privatekey", "secretkey", "sessionkey", "signingkey"}
+
+// lastKeySegment returns the last word of key, lower-cased, splitting on
+// separators and camelCase: "tls.key", "ssh_key" and "APIKey" give "key".
+func lastKeySegment(key string) string {
+	s := key[strings.LastIndexAny(key, "_-./ ")+1:]
+	isUpper := func(c byte) bool { return 'A' <= c && c <= 'Z' }
+	isLower := func(c byte) bool { return 'a' <= c && c <= 'z' }
+	for i := len(s) - 1; i > 0; i-- {
+		if isUpper(s[i]) && (isLower(s[i-1]) || i+1 < len(s) && isLower(s[i+1]) && isUpper(s[i-1])) {
+			s = s[i:]
+			break
+		}
+	}
+	return strings.ToLower(s)
+}
@@ -0,0 +1,8 @@
+//go:build !unix
+
+package logx
+
+import "go.uber.org/fx"
+
+// watchLevelSignals is a no-op where SIGUSR1 and SIGUSR2 do not exist.
+func watchLevelSignals(fx.Lifecycle, LoggerConfig, *LevelController) {}
@@ -0,0 +1,50 @@
+//go:build unix
+
+package logx
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// watchLevelSignals switches the level to debug on SIGUSR1 and back to the
+// configured level on SIGUSR2 while the app runs.
+func watchLevelSignals(lc fx.Lifecycle, c LoggerConfig, levels *LevelController) {
+	if !c.LevelSignals {
+		return
+	}
+
+	sigs := make(chan os.Signal, 1)
+	done := make(chan struct{})
+	lc.Append(fx.Hook{
+		OnStart: func(context.Context) error {
+			signal.Notify(sigs, syscall.SIGUSR1, syscall.SIGUSR2)
+			go func() {
+				for {
+					select {
+					case sig := <-sigs:
+						if sig == syscall.SIGUSR1 {
+							levels.SetLevel(zapcore.DebugLevel, "signal", zap.Stringer("signal", sig))
+						} else {
+							levels.Reset("signal", zap.Stringer("signal", sig))
+						}
+					case <-done:
+						return
+					}
+				}
+			}()
+			return nil
+		},
+		OnStop: func(context.Context) error {
+			signal.Stop(sigs)
+			close(done)
+			return nil
+		},
+	})
+}
@@ -0,0 +1,36 @@
+//go:build unix
+
+package logx
+
+import (
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/fx"
+	"go.uber.org/fx/fxtest"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestWatchLevelSignals(t *testing.T) {
+	levels, logs := newObservedLevels("info")
+	app := fxtest.New(t,
+		fx.Supply(LoggerConfig{LevelSignals: true}, levels),
+		fx.Invoke(watchLevelSignals),
+	)
+	app.RequireStart()
+	defer app.RequireStop()
+
+	require.NoError(t, syscall.Kill(syscall.Getpid(), syscall.SIGUSR1))
+	assert.Eventually(t, func() bool { return levels.Level() == zapcore.DebugLevel }, time.Second, 5*time.Millisecond)
+
+	require.NoError(t, syscall.Kill(syscall.Getpid(), syscall.SIGUSR2))
+	assert.Eventually(t, func() bool { return levels.Level() == zapcore.InfoLevel }, time.Second, 5*time.Millisecond)
+
+	entries := auditEntries(logs)
+	require.Len(t, entries, 2)
+	assert.Equal(t, "signal", entries[0].ContextMap()["source"])
+	assert.Equal(t, "user defined signal 1", entries[0].ContextMap()["signal"])
+}
@@ -0,0 +1,108 @@
+package logx
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gostratum/core/configx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/fx"
+	"go.uber.org/fx/fxtest"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestSinks_Resolve(t *testing.T) {
+	cfg := zap.NewProductionConfig()
+	cfg.Sampling = &zap.SamplingConfig{Initial: 100, Thereafter: 100}
+
+	// Without sinks the top-level settings form the only sink
+	resolved, err := sinks(LoggerConfig{Outputs: []OutputConfig{{Path: "stdout"}}}, cfg)
+	require.NoError(t, err)
+	require.Len(t, resolved, 1)
+	assert.Equal(t, []OutputConfig{{Path: "stdout"}}, resolved[0].outputs)
+	assert.Equal(t, "json", resolved[0].encoding)
+	assert.Equal(t, cfg.Level, resolved[0].level)
+	assert.Equal(t, cfg.Sampling, resolved[0].sampling)
+
+	resolved, err = sinks(LoggerConfig{Sinks: []SinkConfig{
+		{},
+		{Encoding: "console", Level: "debug", SamplingInitial: 10},
+	}}, cfg)
+	require.NoError(t, err)
+	require.Len(t, resolved, 2)
+	assert.Equal(t, "json", resolved[0].encoding)
+	assert.Equal(t, cfg.Level, resolved[0].level)
+	assert.Nil(t, resolved[0].sampling)
+	assert.Equal(t, "console", resolved[1].encoding)
+	assert.Equal(t, zapcore.DebugLevel, resolved[1].level)
+	assert.Equal(t, &zap.SamplingConfig{Initial: 10, Thereafter: 1}, resolved[1].sampling)
+
+	_, err = sinks(LoggerConfig{Sinks: []SinkConfig{{Level: "loud"}}}, cfg)
+	assert.ErrorContains(t, err, `invalid level "loud" for log sink 0`)
+}
+
+func TestSink_UnknownEncoding(t *testing.T) {
+	_, err := sink{encoding: "xml", level: zapcore.InfoLevel}.core(zap.NewProductionEncoderConfig(), zapcore.AddSync(os.Stdout), nil, nil)
+	assert.ErrorContains(t, err, `unknown log encoding "xml"`)
+}
+
+func TestModule_Sinks(t *testing.T) {
+	dir := t.TempDir()
+	jsonPath, consolePath := filepath.Join(dir, "app.json"), filepath.Join(dir, "debug.log")
+	loader, err := configx.NewWithReader(strings.NewReader(fmt.Sprintf(`
+core:
+  logger:
+    env: prod
+    level: info
+    dump_config: false
+    levels:
+      kafka: error
+    sinks:
+      - outputs: [%s]
+      - outputs: [%s]
+        encoding: console
+        level: debug
+`, jsonPath, consolePath)))
+	require.NoError(t, err)
+
+	var (
+		logger *zap.Logger
+		levels *LevelController
+	)
+	app := fxtest.New(t,
+		fx.Supply(fx.Annotate(loader, fx.As(new(configx.Loader)))),
+		Module(),
+		fx.Populate(&logger, &levels),
+	)
+	app.RequireStart()
+	logger.Info("info entry")
+	logger.Debug("debug entry")
+	logger.Named("kafka").Warn("kafka warn")
+	// The first sink follows the controller, the second keeps its level
+	levels.SetLevel(zapcore.ErrorLevel, "test")
+	logger.Warn("warn entry")
+	app.RequireStop()
+
+	b, err := os.ReadFile(jsonPath)
+	require.NoError(t, err)
+	jsonLog := string(b)
+	assert.Contains(t, jsonLog, `"msg":"info entry"`)
+	assert.NotContains(t, jsonLog, "debug entry")
+	assert.NotContains(t, jsonLog, "kafka warn")
+	assert.NotContains(t, jsonLog, "warn entry")
+	assert.Contains(t, jsonLog, `"msg":"log level changed"`)
+
+	b, err = os.ReadFile(consolePath)
+	require.NoError(t, err)
+	consoleLog := string(b)
+	assert.Contains(t, consoleLog, "info entry")
+	assert.Contains(t, consoleLog, "debug entry")
+	assert.Contains(t, consoleLog, "warn entry")
+	assert.NotContains(t, consoleLog, "kafka warn")
+	assert.NotContains(t, consoleLog, `"msg"`)
+}
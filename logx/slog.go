@@ -0,0 +1,228 @@
+package logx
+
+import (
+	"context"
+	"io"
+	"log"
+	"log/slog"
+	"runtime"
+
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// SlogHandlerOptions configures NewSlogHandler.
+type SlogHandlerOptions struct {
+	// AddSource logs the caller of slog records
+	AddSource bool
+}
+
+// slogHandler is a slog.Handler writing to the core of a zap logger, so
+// slog records share the logger's outputs, encoding, levels, sampling and
+// redaction.
+type slogHandler struct {
+	core zapcore.Core
+	name string
+	opts SlogHandlerOptions
+	// groups are opened by WithGroup but not yet added to core, since slog
+	// omits groups without attributes
+	groups []string
+}
+
+// NewSlogHandler returns a slog.Handler backed by l. Attributes become zap
+// fields, groups nested objects, and values are redacted as by Any; the
+// IDs found in the record's context (see ContextFields) are attached.
+//
+// logx.Module provides a *slog.Logger using it; set
+// core.logger.slog_default to also make it slog.Default().
+func NewSlogHandler(l *zap.Logger, opts *SlogHandlerOptions) slog.Handler {
+	h := &slogHandler{core: l.Core(), name: l.Name()}
+	if opts != nil {
+		h.opts = *opts
+	}
+	return h
+}
+
+func (h *slogHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return h.core.Enabled(zapLevel(level))
+}
+
+func (h *slogHandler) Handle(ctx context.Context, r slog.Record) error {
+	ent := zapcore.Entry{
+		Level:      zapLevel(r.Level),
+		Time:       r.Time,
+		LoggerName: h.name,
+		Message:    r.Message,
+	}
+	if h.opts.AddSource && r.PC != 0 {
+		frame, _ := runtime.CallersFrames([]uintptr{r.PC}).Next()
+		ent.Caller = zapcore.NewEntryCaller(frame.PC, frame.File, frame.Line, true)
+		ent.Caller.Function = frame.Function
+	}
+	ce := h.core.Check(ent, nil)
+	if ce == nil {
+		return nil
+	}
+
+	fields := ContextFields(ctx)
+	var attrs []Field
+	r.Attrs(func(a slog.Attr) bool {
+		attrs = appendAttr(attrs, a)
+		return true
+	})
+	if len(attrs) > 0 {
+		fields = append(fields, h.openGroups()...)
+		fields = append(fields, attrs...)
+	}
+	ce.Write(fields...)
+	return nil
+}
+
+func (h *slogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	var fields []Field
+	for _, a := range attrs {
+		fields = appendAttr(fields, a)
+	}
+	if len(fields) == 0 {
+		return h
+	}
+	clone := *h
+	clone.core = h.core.With(append(h.openGroups(), fields...))
+	clone.groups = nil
+	return &clone
+}
+
+func (h *slogHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	clone := *h
+	clone.groups = append(h.groups[:len(h.groups):len(h.groups)], name)
+	return &clone
+}
+
+// openGroups returns namespace fields for the pending groups, which nest
+// all later fields.
+func (h *slogHandler) openGroups() []Field {
+	fields := make([]Field, len(h.groups))
+	for i, g := range h.groups {
+		fields[i] = zap.Namespace(g)
+	}
+	return fields
+}
+
+// appendAttr appends a as a field. Secret keys are masked, other values
+// redacted as by Any.
+func appendAttr(fields []Field, a slog.Attr) []Field {
+	a.Value = a.Value.Resolve()
+	if a.Equal(slog.Attr{}) {
+		return fields
+	}
+	if a.Value.Kind() == slog.KindGroup {
+		attrs := a.Value.Group()
+		if len(attrs) == 0 {
+			return fields
+		}
+		if a.Key == "" {
+			// Inlined, as by slog's own handlers
+			for _, ga := range attrs {
+				fields = appendAttr(fields, ga)
+			}
+			return fields
+		}
+		return append(fields, zap.Object(a.Key, slogGroup(attrs)))
+	}
+
+	if r := activeRedactor.Load(); r.isSecret(a.Key) {
+		return append(fields, zap.String(a.Key, r.maskValue(a.Value.Any())))
+	}
+	v := a.Value
+	switch v.Kind() {
+	case slog.KindString:
+		return append(fields, zap.String(a.Key, v.String()))
+	case slog.KindInt64:
+		return append(fields, zap.Int64(a.Key, v.Int64()))
+	case slog.KindUint64:
+		return append(fields, zap.Uint64(a.Key, v.Uint64()))
+	case slog.KindFloat64:
+		return append(fields, zap.Float64(a.Key, v.Float64()))
+	case slog.KindBool:
+		return append(fields, zap.Bool(a.Key, v.Bool()))
+	case slog.KindDuration:
+		return append(fields, zap.Duration(a.Key, v.Duration()))
+	case slog.KindTime:
+		return append(fields, zap.Time(a.Key, v.Time()))
+	default:
+		if err, ok := v.Any().(error); ok {
+			return append(fields, zap.NamedError(a.Key, err))
+		}
+		return append(fields, Any(a.Key, v.Any()))
+	}
+}
+
+// slogGroup encodes the attributes of a group as an object.
+type slogGroup []slog.Attr
+
+func (g slogGroup) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	var fields []Field
+	for _, a := range g {
+		fields = appendAttr(fields, a)
+	}
+	for _, f := range fields {
+		f.AddTo(enc)
+	}
+	return nil
+}
+
+// zapLevel maps slog levels, including custom ones in between, to zap's.
+func zapLevel(l slog.Level) zapcore.Level {
+	switch {
+	case l < slog.LevelInfo:
+		return zapcore.DebugLevel
+	case l < slog.LevelWarn:
+		return zapcore.InfoLevel
+	case l < slog.LevelError:
+		return zapcore.WarnLevel
+	default:
+		return zapcore.ErrorLevel
+	}
+}
+
+// NewSlogLogger returns a *slog.Logger writing to l, logging callers when
+// the config enables them.
+func NewSlogLogger(c LoggerConfig, l *zap.Logger) *slog.Logger {
+	return slog.New(NewSlogHandler(l, &SlogHandlerOptions{AddSource: c.Caller}))
+}
+
+// setSlogDefault makes l slog.Default(), and so the target of the standard
+// log package, while the app runs. On stop, the previous default and the
+// output, flags and prefix of the log package are restored.
+func setSlogDefault(lc fx.Lifecycle, c LoggerConfig, l *slog.Logger) {
+	if !c.SlogDefault {
+		return
+	}
+	var (
+		previous *slog.Logger
+		out      io.Writer
+		flags    int
+		prefix   string
+	)
+	lc.Append(fx.Hook{
+		OnStart: func(context.Context) error {
+			previous = slog.Default()
+			out, flags, prefix = log.Writer(), log.Flags(), log.Prefix()
+			slog.SetDefault(l)
+			return nil
+		},
+		OnStop: func(context.Context) error {
+			// SetDefault leaves the log package alone when previous is the
+			// built-in default, so it is restored separately
+			slog.SetDefault(previous)
+			log.SetOutput(out)
+			log.SetFlags(flags)
+			log.SetPrefix(prefix)
+			return nil
+		},
+	})
+}
@@ -0,0 +1,201 @@
+package logx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gostratum/core/configx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/fx"
+	"go.uber.org/fx/fxtest"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func newObservedSlog(level zapcore.Level, opts *SlogHandlerOptions) (*slog.Logger, *observer.ObservedLogs) {
+	core, logs := observer.New(level)
+	return slog.New(NewSlogHandler(zap.New(core).Named("app"), opts)), logs
+}
+
+func TestSlogHandler_Levels(t *testing.T) {
+	logger, logs := newObservedSlog(zapcore.InfoLevel, nil)
+	ctx := context.Background()
+
+	assert.False(t, logger.Enabled(ctx, slog.LevelDebug))
+	assert.True(t, logger.Enabled(ctx, slog.LevelInfo))
+	logger.Debug("dropped")
+	logger.Info("info")
+	logger.Log(ctx, slog.LevelInfo+2, "notice")
+	logger.Warn("warn")
+	logger.Error("error")
+	logger.Log(ctx, slog.LevelError+4, "fatal-ish")
+
+	var got []zapcore.Level
+	for _, e := range logs.All() {
+		got = append(got, e.Level)
+		assert.Equal(t, "app", e.LoggerName)
+	}
+	assert.Equal(t, []zapcore.Level{
+		zapcore.InfoLevel, zapcore.InfoLevel, zapcore.WarnLevel, zapcore.ErrorLevel, zapcore.ErrorLevel,
+	}, got)
+}
+
+type slogUser struct {
+	Name     string
+	Password string
+}
+
+func TestSlogHandler_Attrs(t *testing.T) {
+	logger, logs := newObservedSlog(zapcore.DebugLevel, nil)
+	ts := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	logger.Info("attrs",
+		"s", "v",
+		"i", -3,
+		"u", uint64(7),
+		"f", 1.5,
+		"b", true,
+		"d", time.Second,
+		"t", ts,
+		"err", errors.New("boom"),
+		"user", slogUser{Name: "n", Password: "hunter2"},
+		"api_token", "abc",
+		slog.Group("req", "method", "GET", slog.Group("empty")),
+		slog.Group("", "inlined", 1),
+		slog.Attr{},
+	)
+
+	require.Equal(t, 1, logs.Len())
+	assert.Equal(t, map[string]any{
+		"s":         "v",
+		"i":         int64(-3),
+		"u":         uint64(7),
+		"f":         1.5,
+		"b":         true,
+		"d":         time.Second,
+		"t":         ts,
+		"err":       "boom",
+		"user":      map[string]any{"Name": "n", "Password": "[redacted]"},
+		"api_token": "[redacted]",
+		"req":       map[string]any{"method": "GET"},
+		"inlined":   int64(1),
+	}, logs.All()[0].ContextMap())
+}
+
+func TestSlogHandler_Groups(t *testing.T) {
+	logger, logs := newObservedSlog(zapcore.DebugLevel, nil)
+
+	base := logger.With("svc", "api").WithGroup("http").With("route", "/users").WithGroup("resp")
+	base.Info("with attrs", "status", 200)
+	base.Info("no attrs")
+	logger.WithGroup("unused").Info("plain", "k", "v")
+
+	all := logs.All()
+	require.Len(t, all, 3)
+	assert.Equal(t, map[string]any{
+		"svc":  "api",
+		"http": map[string]any{"route": "/users", "resp": map[string]any{"status": int64(200)}},
+	}, all[0].ContextMap())
+	// Empty groups are omitted
+	assert.Equal(t, map[string]any{"svc": "api", "http": map[string]any{"route": "/users"}}, all[1].ContextMap())
+	assert.Equal(t, map[string]any{"unused": map[string]any{"k": "v"}}, all[2].ContextMap())
+}
+
+func TestSlogHandler_ContextAndSource(t *testing.T) {
+	logger, logs := newObservedSlog(zapcore.DebugLevel, &SlogHandlerOptions{AddSource: true})
+	ctx := AddFields(WithRequestID(context.Background(), "req-1"), zap.String("tenant", "t1"))
+
+	logger.InfoContext(ctx, "handled", "n", 1)
+
+	require.Equal(t, 1, logs.Len())
+	e := logs.All()[0]
+	assert.Equal(t, map[string]any{"request_id": "req-1", "tenant": "t1", "n": int64(1)}, e.ContextMap())
+	require.True(t, e.Caller.Defined)
+	assert.Equal(t, "slog_test.go", filepath.Base(e.Caller.File))
+	assert.Contains(t, e.Caller.Function, "TestSlogHandler_ContextAndSource")
+}
+
+func TestSlogHandler_Redactor(t *testing.T) {
+	useRedactor(t, RedactConfig{Keys: []string{"ssn"}, Mask: "last", KeepLast: 2})
+	logger, logs := newObservedSlog(zapcore.DebugLevel, nil)
+
+	logger.Info("user", "ssn", "123-45-6789", slog.Group("g", "password", "hunter22"))
+
+	require.Equal(t, 1, logs.Len())
+	assert.Equal(t, map[string]any{
+		"ssn": "****89",
+		"g":   map[string]any{"password": "****22"},
+	}, logs.All()[0].ContextMap())
+}
+
+func TestModule_Slog(t *testing.T) {
+	previous := slog.Default()
+	stdOut, flags, prefix := log.Writer(), log.Flags(), log.Prefix()
+	t.Cleanup(func() {
+		log.SetOutput(stdOut)
+		log.SetFlags(flags)
+		log.SetPrefix(prefix)
+	})
+	var std strings.Builder
+	log.SetOutput(&std)
+	log.SetFlags(log.Lshortfile)
+	log.SetPrefix("app: ")
+
+	path := filepath.Join(t.TempDir(), "app.log")
+	loader, err := configx.NewWithReader(strings.NewReader(fmt.Sprintf(`
+core:
+  logger:
+    env: prod
+    dump_config: false
+    level: info
+    outputs: [%s]
+    slog_default: true
+    scrub:
+      enabled: true
+`, path)))
+	require.NoError(t, err)
+
+	var logger *slog.Logger
+	app := fxtest.New(t,
+		fx.Supply(fx.Annotate(loader, fx.As(new(configx.Loader)))),
+		Module(),
+		fx.Populate(&logger),
+	)
+	app.RequireStart()
+	assert.Same(t, logger, slog.Default())
+	logger.Debug("hidden")
+	slog.Info("via default", "secret", "s3cr3t", "auth", "Bearer abc")
+	log.Print("via log")
+	app.RequireStop()
+	assert.Same(t, previous, slog.Default())
+
+	// The log package writes where it did before
+	assert.Same(t, &std, log.Writer())
+	assert.Equal(t, log.Lshortfile, log.Flags())
+	assert.Equal(t, "app: ", log.Prefix())
+	log.Print("after stop")
+	assert.Contains(t, std.String(), "app: slog_test.go:")
+	assert.Contains(t, std.String(), "after stop")
+	assert.NotContains(t, std.String(), "via log")
+
+	b, err := os.ReadFile(path)
+	require.NoError(t, err)
+	out := string(b)
+	assert.NotContains(t, out, "hidden")
+	assert.Contains(t, out, `"msg":"via default"`)
+	assert.Contains(t, out, `"secret":"[redacted]"`)
+	assert.Contains(t, out, `"auth":"Bearer [redacted]"`)
+	// slog.SetDefault keeps the log prefix
+	assert.Contains(t, out, `"msg":"app: via log"`)
+	assert.Contains(t, out, `"caller":"logx/slog_test.go:`)
+}